@@ -4,88 +4,609 @@ package fasta
 import (
 	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"math"
+	"math/big"
 	"math/rand"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 const (
-	DefaultLineLength = 70
+	// NoWrap is the line length of sequences written without line breaks. It has the same meaning for String, Format, Writer, Rewrap, and WriteQual.
+	NoWrap                  = 0
+	DefaultLineLength       = 70
+	DefaultProgressInterval = 1 << 20
+	// DefaultBufferSize is the default size of the read buffer of a Scanner.
+	DefaultBufferSize     = 64 << 10
+	DefaultQualityOffset  = 33
+	ReverseComplementMark = " (rc)"
+	StatsTableHeader      = "ID\tLength\tGC\tN\tLowercase\tMD5"
+	// KeepLineLength tells Format and Writer to wrap each sequence at its own line length.
+	KeepLineLength = math.MinInt32
+	// TelomereReportHeader is the header of the table written by WriteTelomereReport.
+	TelomereReportHeader = "ID\tLength\tFivePrime\tThreePrime"
+	// DefaultSketchSeed is the hash seed used by Sketch.
+	DefaultSketchSeed = 42
+	sketchMagic       = "MHS1"
+	ncbiRetries       = 3
+	// iupacCodes maps nucleotide masks to IUPAC codes.
+	iupacCodes      = "-TCYAWMHGKSBRDVN"
+	lintLongLine    = 1 << 20
+	summaryResidues = 10
 )
 
 var dic []byte
+var dicOnce sync.Once
+var sequencePool = sync.Pool{
+	New: func() interface{} {
+		return new(Sequence)
+	},
+}
+
+// ErrNoHeader is wrapped by errors on data before the first header.
+var ErrNoHeader = errors.New("data before first header")
+
+// ErrEmptyRecord is wrapped by errors on records without data where data is required.
+var ErrEmptyRecord = errors.New("empty record")
+
+// ErrDuplicateID is wrapped by errors on identifiers or names that occur more than once where they must be unique.
+var ErrDuplicateID = errors.New("duplicate ID")
+
+// ErrRecordTooLarge is wrapped by errors on records exceeding a size limit.
+var ErrRecordTooLarge = errors.New("record too large")
+
+// ErrUnequalLengths is wrapped by errors on sequences whose lengths should match but don't, like residues and their quality values.
+var ErrUnequalLengths = errors.New("unequal lengths")
+
+// ErrUnknownSequence is wrapped by errors on sequence names that can't be found, for example by Fetch.
+var ErrUnknownSequence = errors.New("unknown sequence")
+
+// geneticCodes maps NCBI genetic code IDs to their amino acids.
+var geneticCodes = map[int]string{
+	1:  "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	2:  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSS**VVVVAAAADDEEGGGG",
+	3:  "FFLLSSSSYY**CCWWTTTTPPPPHHQQRRRRIIMMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	4:  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	5:  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSSSVVVVAAAADDEEGGGG",
+	6:  "FFLLSSSSYYQQCC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	9:  "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+	10: "FFLLSSSSYY**CCCWLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	11: "FFLLSSSSYY**CC*WLLLLPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	12: "FFLLSSSSYY**CC*WLLLSPPPPHHQQRRRRIIIMTTTTNNKKSSRRVVVVAAAADDEEGGGG",
+	13: "FFLLSSSSYY**CCWWLLLLPPPPHHQQRRRRIIMMTTTTNNKKSSGGVVVVAAAADDEEGGGG",
+	14: "FFLLSSSSYYY*CCWWLLLLPPPPHHQQRRRRIIIMTTTTNNNKSSSSVVVVAAAADDEEGGGG",
+}
+var transMu sync.Mutex
+var transTables = make(map[int]*[4096]byte)
+
+// defaultRand is the package-default source of randomness.
+var defaultRand = rand.New(&lockedSource{
+	src: rand.NewSource(time.Now().UnixNano()).(rand.Source64)})
+
+// ErrFrozen is wrapped by errors on attempts to change a frozen Sequence.
+var ErrFrozen = errors.New("sequence is frozen")
+
+// ErrNotSeekable is wrapped by errors on attempts to index input that can't be read at random positions, like the standard input.
+var ErrNotSeekable = errors.New("input not seekable, can't index it")
+var ncbiEfetchURL = "https://eutils.ncbi.nlm.nih.gov/entrez/eutils/efetch.fcgi"
+var ncbiBatchSize = 200
+var ncbiBackoff = time.Second
+var ncbiMu sync.Mutex
+var ncbiLast time.Time
+
+// ErrExpansionLimit is wrapped by errors on expansions that would exceed their limit.
+var ErrExpansionLimit = errors.New("expansion limit exceeded")
+
+// ErrOutOfRange is wrapped by errors on indexes outside a sequence.
+var ErrOutOfRange = errors.New("index out of range")
+
+// ErrStaleIndex is wrapped by errors on indexes or dictionaries that don't match their FASTA file.
+var ErrStaleIndex = errors.New("index doesn't match FASTA")
+
+// ErrLineLength is wrapped by errors on negative line lengths.
+var ErrLineLength = errors.New("negative line length")
 
 // Sequence holds a nucleotide or protein sequence.
 type Sequence struct {
 	header     string
 	data       []byte
 	lineLength int
+	lazy       *lazyData
+	meta       map[string]interface{}
+	frozen     bool
+	rawHeader  string
+	hash       uint64
+	hashed     bool
 }
 
 // A Sequence is read using a Scanner.
 type Scanner struct {
-	r                             *bufio.Reader
-	line                          []byte
-	err                           error
-	isHeader                      bool
-	lastSequence                  bool
-	previousHeader, currentHeader string
-	firstSequence                 bool
-	data                          []byte
+	r                              *bufio.Reader
+	long                           []byte
+	line                           []byte
+	err                            error
+	offset, lineStart              int64
+	lineNumber                     int
+	isHeader                       bool
+	lastSequence                   bool
+	previousHeader, currentHeader  string
+	firstSequence                  bool
+	records                        int
+	data                           []byte
+	progress                       func(int64, int)
+	progressInterval, nextProgress int64
+	counter                        *countingReader
+	bufferSize                     int
+	capacity                       int
+	handoff                        bool
+	strictHeaders                  bool
+	preserveWrap                   bool
+	wrap, lastWrap                 int
+	inconsistent, lastInconsistent bool
+	shortLine                      bool
+	rawHeaders                     bool
+	previousRaw, currentRaw        string
+	lint                           bool
+	irregular                      []Irregularity
+	lintID                         string
+	firstEnding                    string
+	mixedEndings                   bool
+	lintWidth                      int
+	lintShort, lintRagged          bool
+}
+
+// A ScannerOption changes a setting of a Scanner.
+type ScannerOption func(*Scanner)
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// A MultiScanner reads the sequences from several inputs as one stream, first all sequences of the first reader, then those of the second, and so on.
+type MultiScanner struct {
+	readers []io.Reader
+	index   int
+	sc      *Scanner
+	err     error
+}
+
+// A PairScanner reads two inputs in lockstep and returns pairs of sequences, such as the first and second reads of paired-end sequencing.
+type PairScanner struct {
+	sc1, sc2 *Scanner
+	s1, s2   *Sequence
+	n        int
+	err      error
+}
+
+// A FastqRecord is a Sequence with a quality string.
+type FastqRecord struct {
+	Sequence
+	quality []byte
+}
+
+// A FastqScanner reads FASTQ records.
+type FastqScanner struct {
+	lineReader
+	rec *FastqRecord
+	n   int
+}
+type lineReader struct {
+	r   *bufio.Reader
+	err error
+}
+
+// A QualSequence is a Sequence with a quality value for each residue.
+type QualSequence struct {
+	Sequence
+	quality []byte
+	offset  int
+}
+
+// A SortKey compares two sequences and returns a negative number if a comes first, a positive number if b comes first, and zero if they tie. Any function of this signature is a custom SortKey.
+type SortKey func(a, b *Sequence) int
+
+// AssemblyStats holds summary statistics of a set of sequences. Lengths count all residues. N50 and N90 are the lengths of the sequences that bring the cumulative length, adding the longest first, to at least 50 and 90 percent of TotalLength; L50 is the number of sequences needed for N50. GC is the fraction of G and C among the unambiguous nucleotides A, C, G, and T, in either case. Unlike Sequence.GC, it ignores S and doesn't count N and other ambiguity codes in the denominator. NCount counts N and n, LowercaseCount all lowercase residues.
+type AssemblyStats struct {
+	Count                    int
+	TotalLength              int
+	MinLength, MaxLength     int
+	MeanLength, MedianLength float64
+	N50, N90, L50            int
+	GC                       float64
+	NCount                   int
+	LowercaseCount           int
+}
+type statsCounter struct {
+	lengths            []int
+	gc, acgt, n, lower int
+}
+
+// A CoordMap records where each original sequence lies in a concatenated sequence.
+type CoordMap struct {
+	names        []string
+	starts, ends []int
+}
+type reservoir struct {
+	seqs    []*Sequence
+	pos     []int
+	n, seen int
+}
+
+// A DiffReport lists the identifiers found only in the first input, only in the second, and in both with identical data, each in input order, and the records found in both with different data.
+type DiffReport struct {
+	OnlyA, OnlyB, Identical []string
+	Different               []RecordDiff
+}
+
+// A RecordDiff describes the difference between two records with the same identifier. CaseOnly is true if the data only differs in case, StrandOnly if the second record is the reverse complement of the first, ignoring case.
+type RecordDiff struct {
+	ID                   string
+	LengthA, LengthB     int
+	CaseOnly, StrandOnly bool
+}
+type digests struct {
+	length                int
+	exact, upper, rcUpper [md5.Size]byte
+}
+
+// MergePolicy determines how Merge resolves identifier collisions: MergeError returns an error, KeepFirst keeps the first record, KeepLast the last, RenameWithSuffix renames later records by appending "_2", "_3", and so on to their identifier, and KeepIfIdentical keeps the first record if the data is identical and returns an error otherwise.
+type MergePolicy int
+
+const (
+	MergeError MergePolicy = iota
+	KeepFirst
+	KeepLast
+	RenameWithSuffix
+	KeepIfIdentical
+)
+
+// A MergeReport gives for each input the number of records written and dropped, the number of collisions, and the identifiers given to renamed records.
+type MergeReport struct {
+	Written, Dropped []int
+	Collisions       int
+	Renamed          []string
+}
+type mergeEntry struct {
+	sum [md5.Size]byte
+	pos int
+}
+type bedRecord struct {
+	chrom      string
+	start, end int
+	name       string
+	strand     byte
+	line       int
+}
+
+// FaiEntry is a line in a FASTA index.
+type FaiEntry struct {
+	Name                 string
+	Length               int
+	Offset               int64
+	LineBases, LineWidth int
+}
+
+// Faidx fetches regions from indexed FASTA data.
+type Faidx struct {
+	r       io.ReaderAt
+	entries []FaiEntry
+	index   map[string]int
+	closer  func() error
+}
+
+// MmapFasta is a Faidx that reads from a memory-mapped file.
+type MmapFasta struct {
+	*Faidx
+	mapped bool
+}
+type lazyData struct {
+	fx     *Faidx
+	name   string
+	length int
+}
+
+// pathReaderAt is the path of a file read via ReadAt.
+type pathReaderAt string
+
+// A Builder assembles a Sequence piece by piece.
+type Builder struct {
+	header string
+	data   []byte
+}
+type fastaReader struct {
+	s    *Sequence
+	head []byte
+	l    int
+	pos  int64
+}
+
+// A Writer writes sequences in FASTA format with a common line length, which overrides the line lengths of the sequences.
+type Writer struct {
+	w          *bufio.Writer
+	lineLength int
+	rawHeaders bool
+}
+
+// ErrInvalidResidue describes a residue not allowed in a sequence, given by the ID of its record, the line, counted from one, the offset in the sequence, counted from zero, and the byte itself.
+type ErrInvalidResidue struct {
+	ID     string
+	Line   int
+	Offset int
+	Byte   byte
+}
+
+// TandemRepeat is a perfect tandem repeat of Copies copies of Unit covering the zero-based, half-open interval from Start to End.
+type TandemRepeat struct {
+	Start, End int
+	Unit       string
+	Copies     int
+}
+
+// Palindrome is a palindrome of Length residues starting at zero-based position Start, which includes a central spacer of Spacer unpaired residues.
+type Palindrome struct {
+	Start, Length, Spacer int
+}
+
+// STR is a perfect short tandem repeat of Copies copies of Unit on sequence Chrom covering the zero-based, half-open interval from Start to End.
+type STR struct {
+	Chrom      string
+	Start, End int
+	Unit       string
+	Copies     int
+}
+
+// MinHashSketch is a bottom-k MinHash sketch of canonical k-mers.
+type MinHashSketch struct {
+	k, size int
+	seed    uint64
+	hashes  []uint64
+}
+
+// lockedSource is a source of randomness that is safe for concurrent use.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source64
+}
+
+// HTTPError is returned when a server answers with a status other than 200 OK.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+}
+
+// MissingAccessionsError lists the accessions for which the NCBI returned no sequence.
+type MissingAccessionsError struct {
+	Accessions []string
+}
+
+// A WriterOption configures a Writer.
+type WriterOption func(*Writer)
+
+// VerifyReport summarizes the comparison of a FASTA file with its manifest. It lists the IDs of records missing from the FASTA file, of extra records not in the manifest, and of records whose length or checksum doesn't match, and counts the matching records.
+type VerifyReport struct {
+	Missing, Extra, Mismatched []string
+	Matched                    int
+}
+
+// RegionError is the error for the region at Index in a call to FetchMany.
+type RegionError struct {
+	Index  int
+	Region string
+	Err    error
+}
+
+// FetchManyError collects the errors of the regions that FetchMany couldn't fetch, in the order of the regions.
+type FetchManyError []RegionError
+
+// SeqLength is the ID and length of a sequence.
+type SeqLength struct {
+	ID     string
+	Length int
+}
+
+// A View is a read-only region of a Sequence that shares the data of the Sequence rather than copying it. Any change to the data of the Sequence invalidates its views; they may then show the changed residues, or residues the Sequence no longer has. Views of a frozen Sequence stay valid. Call Materialize to keep a region beyond changes of its parent.
+type View struct {
+	parent     *Sequence
+	start, end int
+	data       []byte
+}
+
+// A countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+// IrregularityKind classifies irregularities: BlankLine is an empty line or a line of blanks, TrailingWhitespace a line ending in blanks, InconsistentWidth a data line that breaks the wrapping of its record, and MixedLineEndings a line ending that differs from the first one in the input.
+type IrregularityKind int
+
+const (
+	BlankLine IrregularityKind = iota
+	TrailingWhitespace
+	InconsistentWidth
+	MixedLineEndings
+)
+
+// An Irregularity is found on the one-based line Line of the input in the record with ID ID, which is empty before the first header.
+type Irregularity struct {
+	Kind IrregularityKind
+	Line int
+	ID   string
+}
+
+// Severity grades a LintFinding: SeverityInfo marks what is usually intended, like soft-masking, SeverityWarning what tools may stumble over, and SeverityError what makes the file unfit for use.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarning
+	SeverityError
+)
+
+// A LintFinding is a problem found by Lint. Check names the check that found it, for example duplicate-id. Line is the one-based line of the input where it was found, or 0 if it concerns the file as a whole, and ID the ID of the record concerned, if any.
+type LintFinding struct {
+	Severity Severity `json:"severity"`
+	Check    string   `json:"check"`
+	Line     int      `json:"line,omitempty"`
+	ID       string   `json:"id,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// A LintReport lists the findings of Lint in the order of their lines, after the findings that concern the whole file, and counts the records checked.
+type LintReport struct {
+	Records  int           `json:"records"`
+	Findings []LintFinding `json:"findings"`
+}
+
+// lastByteReader remembers the last byte read through it and counts the bytes.
+type lastByteReader struct {
+	r    io.Reader
+	last byte
+	n    int64
+}
+
+// A linter collects the findings of Lint.
+type linter struct {
+	findings     []LintFinding
+	records      int
+	ids          map[string]int
+	inRecord     bool
+	id           string
+	headerLine   int
+	residues     int
+	upper, lower bool
+	invalid      map[byte]int
+	firstInvalid map[byte][2]int
+	headless     bool
+}
+
+// An AlignmentError lists the reasons why sequences don't form an alignment. If some of them are of deviating length, it matches ErrUnequalLengths in errors.Is.
+type AlignmentError struct {
+	Problems []string
+	unequal  bool
 }
 
+// A PairReport summarizes the comparison of two files of mates. It counts the records in either file, the pairs compared, and the pairs named in each style: with identical IDs, with IDs ending in /1 and /2, and with identical IDs and Casava descriptions starting with 1: and 2:. It also counts the mismatched pairs and gives the zero-based index and the headers of the first one; the index is -1 if there is none.
+type PairReport struct {
+	Records1, Records2       int
+	Pairs                    int
+	Identical, Slash, Casava int
+	Mismatches               int
+	FirstMismatch            int
+	MismatchHeaders          [2]string
+}
+
+// mateNaming is a style of naming mates; styleMismatch stands for names that don't belong to a pair.
+type mateNaming int
+
+const (
+	styleMismatch mateNaming = iota
+	styleIdentical
+	styleSlash
+	styleCasava
+)
+
 func (s *Sequence) Header() string  { return s.header }
-func (s *Sequence) Data() []byte    { return s.data }
 func (s *Sequence) LineLength() int { return s.lineLength }
 
+// Data returns the residues of the Sequence. The slice returned is the data of the Sequence itself, so changing it changes the Sequence, and appending to it may or may not. Use DataCopy to get a copy instead.
+func (s *Sequence) Data() []byte {
+	s.mustLoad()
+	return s.data
+}
+
+// DataCopy returns a copy of the residues of the Sequence.
+func (s *Sequence) DataCopy() []byte {
+	s.mustLoad()
+	d := make([]byte, len(s.data))
+	copy(d, s.data)
+	return d
+}
+
 // SetHeader replaces the existing header.
 func (s *Sequence) SetHeader(h string) {
+	s.mustBeMutable()
 	s.header = h
+	s.rawHeader = ""
 }
 
-// SetData replaces the existing data.
+// SetData replaces the existing data. The Sequence uses d itself rather than a copy.
 func (s *Sequence) SetData(d []byte) {
+	s.mustBeMutable()
 	s.data = d
+	s.lazy = nil
+	s.dataChanged()
 }
 
-// SetLineLength replaces the current line length. If the line length passed is less than 1, it is assumed that effectively infinite lines are requested.
+// SetLineLength replaces the current line length. A line length of NoWrap means the data is written in a single line, and LineLength then returns NoWrap. For backward compatibility, negative line lengths are set to NoWrap as well, while the functions that write sequences reject them.
 func (s *Sequence) SetLineLength(l int) {
+	s.mustBeMutable()
 	s.lineLength = l
 	if s.lineLength < 1 {
-		s.lineLength = math.MaxInt64
+		s.lineLength = NoWrap
 	}
 }
 
 // AppendToHeader appends the suffix suf to the header.
 func (s *Sequence) AppendToHeader(suf string) {
+	s.mustBeMutable()
 	s.header = s.header + suf
+	s.rawHeader = ""
 }
 
-// Equals compares two sequences and returns true if their headers and data are identical.
+// Equals compares two sequences and returns true if their headers and data are identical. Line lengths and metadata are ignored. A nil Sequence equals only another nil Sequence.
 func (a *Sequence) Equals(b *Sequence) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
 	if a.header != b.header {
 		return false
 	}
-	if len(a.data) != len(b.data) {
+	if a.Length() != b.Length() {
+		return false
+	}
+	a.mustLoad()
+	b.mustLoad()
+	if a.frozen && b.frozen && a.hash != b.hash {
 		return false
 	}
 	return bytes.Equal(a.data, b.data)
-	return true
 }
 
 // String wraps the sequence into lines at most lineLength characters long.
 func (s *Sequence) String() string {
+	s.mustLoad()
 	var b []byte
 	b = append(b, '>')
 	b = append(b, s.header...)
 	b = append(b, '\n')
 	var c int
-	for _, r := range s.data {
-		b = append(b, r)
-		c++
-		if c == s.lineLength {
-			c = 0
-			b = append(b, '\n')
+	if s.lineLength < 1 {
+		b = append(b, s.data...)
+		c = len(s.data)
+	} else {
+		for _, r := range s.data {
+			b = append(b, r)
+			c++
+			if c == s.lineLength {
+				c = 0
+				b = append(b, '\n')
+			}
 		}
 	}
 	if c == 0 && len(b) > 0 {
@@ -98,16 +619,22 @@ func (s *Sequence) String() string {
 	}
 }
 
-// Method Shuffle randomizes the residues in a Sequence. The sequence composition remains unchanged.
+// Method Shuffle randomizes the residues in a Sequence. The sequence composition remains unchanged. If r is nil, a package-default source of randomness is used.
 func (s *Sequence) Shuffle(r *rand.Rand) {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
 	d := s.data
-	r.Shuffle(len(d), func(i, j int) {
+	randOrDefault(r).Shuffle(len(d), func(i, j int) {
 		d[i], d[j] = d[j], d[i]
 	})
 }
 
 // Method Reverse reverses the residues of a Sequence.
 func (s *Sequence) Reverse() {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
 	d := s.data
 	for i, j := 0, len(d)-1; i < j; i, j = i+1, j-1 {
 		d[i], d[j] = d[j], d[i]
@@ -116,17 +643,10 @@ func (s *Sequence) Reverse() {
 
 // Complement complements nucleotide sequences.
 func (s *Sequence) Complement() {
-	if dic == nil {
-		dic = make([]byte, 256)
-		f := []byte("ACGTUWSMKRYBDHVNacgtuwsmkrybdhvn")
-		r := []byte("TGCAAWSKMYRVHDBNtgcaawskmyrvhdbn")
-		for i, _ := range dic {
-			dic[i] = byte(i)
-		}
-		for i, v := range f {
-			dic[v] = r[i]
-		}
-	}
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	initDic()
 	for i, v := range s.data {
 		s.data[i] = dic[v]
 	}
@@ -140,46 +660,102 @@ func (s *Sequence) ReverseComplement() {
 
 // Method Length returns the number of residues in Sequence.
 func (s *Sequence) Length() int {
+	if s.lazy != nil {
+		return s.lazy.length
+	}
 	return len(s.data)
 }
 
-// Method GC returns the fraction of GC nucleotides in Sequence.
+// Method GC returns the fraction of G, C, and S nucleotides in Sequence, in either case. The GC content of an empty sequence is 0.
 func (s *Sequence) GC() float64 {
-	l := float64(s.Length())
-	gc := 0.0
-	for _, r := range s.data {
-		if r == 'G' || r == 'C' {
-			gc++
-		}
+	return s.Fraction('G', 'C', 'S', 'g', 'c', 's')
+}
+
+// Counts returns the number of times each byte occurs in the Sequence. It is the common basis of the statistics on residues.
+func (s *Sequence) Counts() [256]uint64 {
+	s.mustLoad()
+	var t [4][256]uint64
+	d := s.data
+	i := 0
+	for ; i+8 <= len(d); i += 8 {
+		t[0][d[i]]++
+		t[1][d[i+1]]++
+		t[2][d[i+2]]++
+		t[3][d[i+3]]++
+		t[0][d[i+4]]++
+		t[1][d[i+5]]++
+		t[2][d[i+6]]++
+		t[3][d[i+7]]++
+	}
+	for ; i < len(d); i++ {
+		t[0][d[i]]++
 	}
-	return gc / l
+	for j := 0; j < 256; j++ {
+		t[0][j] += t[1][j] + t[2][j] + t[3][j]
+	}
+	return t[0]
 }
 
-// ScanLine reads input line by line. It skips empty lines and marks headers. The last call to ScanLine should be followed by a call to Flush to retrieve any bytes not terminated by newline.
+// ScanLine reads input line by line. It skips empty lines, including lines that contain only blanks, and marks headers. The last call to ScanLine should be followed by a call to Flush to retrieve any bytes not terminated by newline.
 func (s *Scanner) ScanLine() bool {
-	var err error
-	s.line, err = s.r.ReadBytes('\n')
-	if err != nil {
-		s.err = err
-		return false
-	}
-	s.line = bytes.TrimRight(s.line, "\r\n")
-	if len(s.line) > 0 {
-		if s.line[0] == '>' {
+	for {
+		var err error
+		s.line, err = s.readLine()
+		s.lineStart = s.offset
+		s.offset += int64(len(s.line))
+		if len(s.line) > 0 {
+			s.lineNumber++
+		}
+		if s.lint && len(s.line) > 0 {
+			s.checkLine(s.line)
+		}
+		if s.progress != nil && s.counter.n >= s.nextProgress {
+			s.progress(s.counter.n, s.records)
+			for s.nextProgress <= s.counter.n {
+				s.nextProgress += s.progressInterval
+			}
+		}
+		if err != nil {
+			s.err = err
+			s.line = bytes.TrimRight(s.line, "\r")
+			if len(s.line) > 0 && s.line[0] != '>' {
+				s.line = bytes.TrimRight(s.line, " \t")
+			}
+			return false
+		}
+		s.line = bytes.TrimRight(s.line, "\r\n")
+		if len(s.line) > 0 && s.line[0] == '>' {
 			s.isHeader = true
-		} else {
-			s.isHeader = false
+			s.err = nil
+			return true
+		}
+		s.isHeader = false
+		s.line = bytes.TrimRight(s.line, " \t")
+		if len(s.line) > 0 {
+			s.err = nil
+			return true
 		}
-		return true
 	}
-	s.err = nil
-	return true
+}
+
+// readLine returns the next line including its newline.
+func (s *Scanner) readLine() ([]byte, error) {
+	line, err := s.r.ReadSlice('\n')
+	if err != bufio.ErrBufferFull {
+		return line, err
+	}
+	s.long = append(s.long[:0], line...)
+	for err == bufio.ErrBufferFull {
+		line, err = s.r.ReadSlice('\n')
+		s.long = append(s.long, line...)
+	}
+	return s.long, err
 }
 func (s *Scanner) IsHeader() bool {
 	return s.isHeader
 }
 
-// Line returns the last non-empty line scanned.
+// Line returns the last non-empty line scanned. The line is only valid until the next call to ScanLine.
 func (s *Scanner) Line() []byte {
 	return s.line
 }
@@ -196,61 +772,6089 @@ func (s *Scanner) Flush() []byte {
 // Sequence returns the last Sequence scanned.
 func (s *Scanner) Sequence() *Sequence {
 	seq := &Sequence{
-		header: s.previousHeader,
+		header:    s.previousHeader,
+		rawHeader: s.previousRaw,
+	}
+	if s.handoff && cap(s.data)-len(s.data) <= len(s.data)/4 {
+		seq.data = s.data
+		seq.lineLength = DefaultLineLength
+		if s.preserveWrap && s.lastWrap > 0 {
+			seq.lineLength = s.lastWrap
+		}
+		s.capacity = len(s.data)
+		s.data = nil
+		return seq
 	}
 	seq.data = make([]byte, len(s.data))
 	copy(seq.data, s.data)
 	seq.lineLength = DefaultLineLength
+	if s.preserveWrap && s.lastWrap > 0 {
+		seq.lineLength = s.lastWrap
+	}
 	s.data = s.data[:0]
 	return seq
 }
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
 
-// Function NewSequence returns a new Sequence.
-func NewSequence(h string, d []byte) *Sequence {
-	s := new(Sequence)
-	s.header = h
-	s.data = make([]byte, len(d))
-	copy(s.data, d)
-	s.lineLength = DefaultLineLength
-	return s
+// Err returns the first error other than io.EOF encountered by the Scanner.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
 }
 
-// ScanSequence reads input Sequence by Sequence.
-func (s *Scanner) ScanSequence() bool {
-	if s.lastSequence {
+// ScanSequence reads the inputs of a MultiScanner Sequence by Sequence. It returns false when all inputs are exhausted or an error occurred.
+func (m *MultiScanner) ScanSequence() bool {
+	for m.err == nil && m.index < len(m.readers) {
+		if m.sc == nil {
+			m.sc = NewScanner(m.readers[m.index])
+		}
+		if m.sc.ScanSequence() {
+			return true
+		}
+		if err := m.sc.Err(); err != nil {
+			m.err = fmt.Errorf("reader %d: %w", m.index, err)
+			return false
+		}
+		m.sc = nil
+		m.index++
+	}
+	return false
+}
+
+// Sequence returns the last Sequence scanned by the MultiScanner.
+func (m *MultiScanner) Sequence() *Sequence {
+	return m.sc.Sequence()
+}
+
+// Index returns the index of the reader the last Sequence came from.
+func (m *MultiScanner) Index() int {
+	return m.index
+}
+
+// Err returns the first error encountered by the MultiScanner, prefixed by the index of the reader it came from.
+func (m *MultiScanner) Err() error {
+	return m.err
+}
+
+// ScanPair reads the next pair of sequences. It returns false at the end of the input or on an error, including inputs with different numbers of sequences and pairs with mismatched names.
+func (p *PairScanner) ScanPair() bool {
+	if p.err != nil {
 		return false
 	}
-	for s.ScanLine() {
-		if s.isHeader {
-			s.previousHeader = s.currentHeader
-			s.currentHeader = string(s.Line()[1:])
-			if s.firstSequence {
-				s.firstSequence = false
-			} else {
-				return true
-			}
-		} else {
-			s.data = append(s.data, s.Line()...)
+	ok1 := p.sc1.ScanSequence()
+	ok2 := p.sc2.ScanSequence()
+	if err := p.sc1.Err(); err != nil {
+		p.err = fmt.Errorf("input 1: %w", err)
+		return false
+	}
+	if err := p.sc2.Err(); err != nil {
+		p.err = fmt.Errorf("input 2: %w", err)
+		return false
+	}
+	if ok1 != ok2 {
+		i := 1
+		if ok2 {
+			i = 2
 		}
+		p.err = fmt.Errorf("pair %d: input %d has more sequences "+
+			"than the other", p.n+1, i)
+		return false
 	}
-	s.lastSequence = true
-	if s.err == io.EOF {
-		s.data = append(s.data, s.Line()...)
+	if !ok1 {
+		return false
 	}
-	s.previousHeader = s.currentHeader
-	if !s.firstSequence {
-		return true
-	} else {
+	p.s1 = p.sc1.Sequence()
+	p.s2 = p.sc2.Sequence()
+	p.n++
+	p.err = checkPair(p.s1, p.s2, p.n)
+	return p.err == nil
+}
+
+// Pair returns the last pair of sequences scanned.
+func (p *PairScanner) Pair() (*Sequence, *Sequence) {
+	return p.s1, p.s2
+}
+
+// Err returns the first error encountered by the PairScanner.
+func (p *PairScanner) Err() error {
+	return p.err
+}
+
+// Quality returns the quality string of a FastqRecord.
+func (f *FastqRecord) Quality() []byte {
+	return f.quality
+}
+
+// ToSequence returns a copy of the Sequence in a FastqRecord, without the qualities.
+func (f *FastqRecord) ToSequence() *Sequence {
+	return NewSequence(f.header, f.data)
+}
+
+// ScanRecord reads the next FASTQ record. It returns false at the end of the input or on an error, which is retrieved with Err.
+func (f *FastqScanner) ScanRecord() bool {
+	if f.err != nil {
 		return false
 	}
+	line, ok := f.nextLine()
+	for ok && len(line) == 0 {
+		line, ok = f.nextLine()
+	}
+	if !ok {
+		return false
+	}
+	f.n++
+	if line[0] != '@' {
+		f.err = fmt.Errorf("record %d: header %q doesn't start "+
+			"with '@'", f.n, line)
+		return false
+	}
+	header := strings.TrimSpace(string(line[1:]))
+	var seq []byte
+	for {
+		line, ok = f.nextLine()
+		if !ok {
+			f.truncated(header)
+			return false
+		}
+		if len(line) > 0 && line[0] == '+' {
+			break
+		}
+		seq = append(seq, line...)
+	}
+	sep := strings.TrimSpace(string(line[1:]))
+	if sep != "" && sep != header {
+		f.err = fmt.Errorf("record %q: separator %q doesn't match "+
+			"header", header, sep)
+		return false
+	}
+	var qual []byte
+	for len(qual) < len(seq) {
+		line, ok = f.nextLine()
+		if !ok {
+			f.truncated(header)
+			return false
+		}
+		qual = append(qual, line...)
+	}
+	if len(qual) != len(seq) {
+		f.err = fmt.Errorf("record %q: %d residues but %d quality "+
+			"values: %w", header, len(seq), len(qual),
+			ErrUnequalLengths)
+		return false
+	}
+	f.rec = &FastqRecord{quality: qual}
+	f.rec.header = header
+	f.rec.data = seq
+	f.rec.lineLength = DefaultLineLength
+	return true
+}
+func (l *lineReader) nextLine() ([]byte, bool) {
+	line, err := l.r.ReadBytes('\n')
+	if err != nil && (err != io.EOF || len(line) == 0) {
+		if err != io.EOF {
+			l.err = err
+		}
+		return nil, false
+	}
+	return bytes.TrimRight(line, "\r\n"), true
+}
+func (f *FastqScanner) truncated(header string) {
+	if f.err == nil {
+		f.err = fmt.Errorf("record %q: unexpected end of input",
+			header)
+	}
 }
 
-// NewScanner returns a new Scanner to read from r.
-func NewScanner(r io.Reader) *Scanner {
-	rd := bufio.NewReader(r)
-	scanner := Scanner{
-		r:             rd,
-		firstSequence: true,
+// Record returns the last record scanned.
+func (f *FastqScanner) Record() *FastqRecord {
+	return f.rec
+}
+
+// Err returns the first error encountered by the FastqScanner.
+func (f *FastqScanner) Err() error {
+	return f.err
+}
+func (q *QualSequence) Quality() []byte    { return q.quality }
+func (q *QualSequence) QualityOffset() int { return q.offset }
+
+// SetQuality replaces the existing quality.
+func (q *QualSequence) SetQuality(d []byte) {
+	q.mustBeMutable()
+	q.quality = d
+}
+
+// SetQualityOffset sets the value subtracted from a quality character to get its Phred score.
+func (q *QualSequence) SetQualityOffset(o int) {
+	q.mustBeMutable()
+	q.offset = o
+}
+
+// MeanQuality returns the mean Phred score of a QualSequence, or 0 if it is empty.
+func (q *QualSequence) MeanQuality() float64 {
+	if len(q.quality) == 0 {
+		return 0
 	}
-	return &scanner
+	sum := 0
+	for _, c := range q.quality {
+		sum += int(c) - q.offset
+	}
+	return float64(sum) / float64(len(q.quality))
+}
+
+// TrimQuality trims a QualSequence to its best segment with respect to the Phred score threshold, following the modified Mott algorithm. If there is no good segment, the sequence becomes empty.
+func (q *QualSequence) TrimQuality(threshold int) {
+	q.mustBeMutable()
+	start, end := 0, 0
+	sum, best, s := 0, 0, 0
+	for i, c := range q.quality {
+		sum += int(c) - q.offset - threshold
+		if sum <= 0 {
+			sum = 0
+			s = i + 1
+		} else if sum > best {
+			best = sum
+			start, end = s, i+1
+		}
+	}
+	q.data = q.data[start:end]
+	q.quality = q.quality[start:end]
+	q.dataChanged()
+}
+
+// QualityEncoding guesses the quality offset of a QualSequence from its quality characters. It returns 33 if any character is less than 64 ('@'), and 64 otherwise. It returns an error if there are no qualities or a character falls outside the printable range.
+func (q *QualSequence) QualityEncoding() (offset int, err error) {
+	if len(q.quality) == 0 {
+		return 0, fmt.Errorf("%q: %w", q.header, ErrEmptyRecord)
+	}
+	min := byte(126)
+	for _, c := range q.quality {
+		if c < 33 || c > 126 {
+			return 0, fmt.Errorf("%q: quality character %q "+
+				"out of range", q.header, c)
+		}
+		if c < min {
+			min = c
+		}
+	}
+	if min < 64 {
+		return 33, nil
+	}
+	return 64, nil
+}
+
+// ToSequence returns a copy of the Sequence in a QualSequence, dropping the qualities.
+func (q *QualSequence) ToSequence() *Sequence {
+	s := NewSequence(q.header, q.data)
+	s.lineLength = q.lineLength
+	return s
+}
+
+// ToQualSequence returns a copy of a Sequence as a QualSequence, where every residue has the quality character qual.
+func (s *Sequence) ToQualSequence(qual byte) *QualSequence {
+	s.mustLoad()
+	q := bytes.Repeat([]byte{qual}, len(s.data))
+	qs, _ := NewQualSequence(s.header, s.data, q)
+	qs.lineLength = s.lineLength
+	return qs
+}
+
+// ToQualSequence converts a FastqRecord into a QualSequence.
+func (f *FastqRecord) ToQualSequence() *QualSequence {
+	qs, _ := NewQualSequence(f.header, f.data, f.quality)
+	return qs
+}
+
+// ID returns the first word of the header.
+func (s *Sequence) ID() string {
+	h := strings.TrimSpace(s.header)
+	if i := strings.IndexAny(h, " \t"); i >= 0 {
+		return h[:i]
+	}
+	return h
+}
+
+// Description returns the header without its first word.
+func (s *Sequence) Description() string {
+	h := strings.TrimSpace(s.header)
+	if i := strings.IndexAny(h, " \t"); i >= 0 {
+		return strings.TrimSpace(h[i:])
+	}
+	return ""
+}
+func (c *statsCounter) add(s *Sequence) {
+	c.lengths = append(c.lengths, s.Length())
+	t := s.Counts()
+	gc := t['G'] + t['C'] + t['g'] + t['c']
+	c.gc += int(gc)
+	c.acgt += int(gc + t['A'] + t['T'] + t['a'] + t['t'])
+	c.n += int(t['N'] + t['n'])
+	c.lower += int(countLower(&t))
+}
+func (c *statsCounter) stats() AssemblyStats {
+	var st AssemblyStats
+	n := len(c.lengths)
+	if n == 0 {
+		return st
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(c.lengths)))
+	st.Count = n
+	for _, l := range c.lengths {
+		st.TotalLength += l
+	}
+	st.MaxLength = c.lengths[0]
+	st.MinLength = c.lengths[n-1]
+	st.MeanLength = float64(st.TotalLength) / float64(n)
+	if n%2 == 1 {
+		st.MedianLength = float64(c.lengths[n/2])
+	} else {
+		st.MedianLength = float64(c.lengths[n/2-1]+c.lengths[n/2]) / 2
+	}
+	cum := 0
+	for i, l := range c.lengths {
+		cum += l
+		if st.N50 == 0 && 2*cum >= st.TotalLength {
+			st.N50 = l
+			st.L50 = i + 1
+		}
+		if st.N90 == 0 && 10*cum >= 9*st.TotalLength {
+			st.N90 = l
+			break
+		}
+	}
+	if c.acgt > 0 {
+		st.GC = float64(c.gc) / float64(c.acgt)
+	}
+	st.NCount = c.n
+	st.LowercaseCount = c.lower
+	return st
+}
+
+// Locate returns the name of the original sequence containing position pos of the concatenated sequence, and the offset of pos in it. Positions outside the concatenated sequence or inside a separator are errors.
+func (m *CoordMap) Locate(pos int) (name string, offset int,
+	err error) {
+	n := len(m.starts)
+	if n == 0 || pos < 0 || pos >= m.ends[n-1] {
+		return "", 0, fmt.Errorf("position %d out of range", pos)
+	}
+	i := sort.Search(n, func(i int) bool {
+		return m.starts[i] > pos
+	}) - 1
+	if pos >= m.ends[i] {
+		return "", 0, fmt.Errorf("position %d lies in separator "+
+			"after %q", pos, m.names[i])
+	}
+	return m.names[i], pos - m.starts[i], nil
+}
+
+// Write writes a CoordMap to w as a table with one row per sequence consisting of name, start, and end separated by tabs. Names containing tabs or newlines are an error.
+func (m *CoordMap) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for i, name := range m.names {
+		if strings.ContainsAny(name, "\t\n") {
+			return fmt.Errorf("name %q contains tab or newline",
+				name)
+		}
+		fmt.Fprintf(bw, "%s\t%d\t%d\n", name, m.starts[i], m.ends[i])
+	}
+	return bw.Flush()
+}
+func (res *reservoir) add(s *Sequence, r *rand.Rand) {
+	if len(res.seqs) < res.n {
+		res.seqs = append(res.seqs, s)
+		res.pos = append(res.pos, res.seen)
+	} else if j := r.Intn(res.seen + 1); j < res.n {
+		res.seqs[j] = s
+		res.pos[j] = res.seen
+	}
+	res.seen++
+}
+func (res *reservoir) sequences() []*Sequence {
+	sort.Sort(res)
+	return res.seqs
+}
+func (res *reservoir) Len() int { return len(res.seqs) }
+func (res *reservoir) Less(i, j int) bool {
+	return res.pos[i] < res.pos[j]
+}
+func (res *reservoir) Swap(i, j int) {
+	res.seqs[i], res.seqs[j] = res.seqs[j], res.seqs[i]
+	res.pos[i], res.pos[j] = res.pos[j], res.pos[i]
+}
+
+// Equal returns true if the two inputs compared contain the same records.
+func (d DiffReport) Equal() bool {
+	return len(d.OnlyA) == 0 && len(d.OnlyB) == 0 &&
+		len(d.Different) == 0
+}
+
+// ComplementParallel complements a nucleotide sequence using up to workers goroutines. The result is the same as that of Complement.
+func (s *Sequence) ComplementParallel(workers int) {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	initDic()
+	d := s.data
+	parallelRanges(len(d), workers, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			d[i] = dic[d[i]]
+		}
+	})
+}
+
+// ReverseComplementParallel reverse-complements a nucleotide sequence using up to workers goroutines. The result is the same as that of ReverseComplement.
+func (s *Sequence) ReverseComplementParallel(workers int) {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	initDic()
+	d := s.data
+	n := len(d)
+	parallelRanges((n+1)/2, workers, func(lo, hi int) {
+		for i := lo; i < hi; i++ {
+			j := n - 1 - i
+			d[i], d[j] = dic[d[j]], dic[d[i]]
+		}
+	})
+}
+
+// position returns the byte offset of residue p.
+func (e FaiEntry) position(p int) int64 {
+	if e.LineBases == 0 {
+		return e.Offset
+	}
+	return e.Offset + int64(p/e.LineBases)*int64(e.LineWidth) +
+		int64(p%e.LineBases)
+}
+
+// Entries returns the index entries.
+func (f *Faidx) Entries() []FaiEntry {
+	return f.entries
+}
+
+// Fetch returns the residues from start up to but not including end of the sequence name. Positions are zero-based, and the result is named name:start-end.
+func (f *Faidx) Fetch(name string, start, end int) (*Sequence, error) {
+	if f.r == nil {
+		return nil, errors.New("faidx is closed")
+	}
+	i, ok := f.index[name]
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownSequence, name)
+	}
+	e := f.entries[i]
+	if start < 0 || start > end || end > e.Length {
+		return nil, fmt.Errorf("%q: invalid range %d-%d for "+
+			"length %d", name, start, end, e.Length)
+	}
+	lo, hi := e.position(start), e.position(end)
+	buf := make([]byte, hi-lo)
+	if n, err := f.r.ReadAt(buf, lo); n < len(buf) {
+		return nil, fmt.Errorf("%q: %w", name, err)
+	}
+	d := buf[:0]
+	for _, c := range buf {
+		if c != '\n' && c != '\r' {
+			d = append(d, c)
+		}
+	}
+	seq := &Sequence{header: fmt.Sprintf("%s:%d-%d", name, start,
+		end), data: d, lineLength: DefaultLineLength}
+	return seq, nil
+}
+
+// Close releases the resources held by the Faidx. Later calls to Fetch return an error.
+func (f *Faidx) Close() error {
+	if f.r == nil {
+		return nil
+	}
+	f.r = nil
+	if f.closer != nil {
+		return f.closer()
+	}
+	return nil
+}
+
+// Mapped reports whether the file is memory-mapped, rather than read.
+func (m *MmapFasta) Mapped() bool {
+	return m.mapped
+}
+
+// Materialize loads the data of a lazy Sequence. It does nothing for other sequences. As it changes the Sequence, it must not run concurrently with other methods, and neither may the methods that load the data implicitly, like Data. So call Materialize or Freeze before sharing a lazy Sequence between goroutines.
+func (s *Sequence) Materialize() error {
+	if s.lazy == nil {
+		return nil
+	}
+	r, err := s.lazy.fx.Fetch(s.lazy.name, 0, s.lazy.length)
+	if err != nil {
+		return err
+	}
+	s.data = r.data
+	s.lazy = nil
+	return nil
+}
+
+// mustLoad materializes a lazy sequence and panics if that fails.
+func (s *Sequence) mustLoad() {
+	if err := s.Materialize(); err != nil {
+		panic(err)
+	}
+}
+
+// ReadAt opens the file, reads from it, and closes it again.
+func (p pathReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	f, err := os.Open(string(p))
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	return f.ReadAt(b, off)
+}
+
+// SequencePooled is like Sequence, except that the returned Sequence is drawn from a pool. Pass it to Recycle once it is no longer needed.
+func (s *Scanner) SequencePooled() *Sequence {
+	seq := sequencePool.Get().(*Sequence)
+	seq.header = s.previousHeader
+	seq.rawHeader = s.previousRaw
+	seq.data = append(seq.data[:0], s.data...)
+	seq.lineLength = DefaultLineLength
+	if s.preserveWrap && s.lastWrap > 0 {
+		seq.lineLength = s.lastWrap
+	}
+	s.data = s.data[:0]
+	return seq
+}
+
+// Grow makes room for at least n more residues.
+func (b *Builder) Grow(n int) {
+	if n < 0 {
+		panic("fasta: negative Builder.Grow count")
+	}
+	if cap(b.data)-len(b.data) < n {
+		d := make([]byte, len(b.data), 2*cap(b.data)+n)
+		copy(d, b.data)
+		b.data = d
+	}
+}
+
+// WriteBytes appends d to the data. It always returns the length of d and a nil error.
+func (b *Builder) WriteBytes(d []byte) (int, error) {
+	b.data = append(b.data, d...)
+	return len(d), nil
+}
+
+// WriteByte appends the residue c to the data. It always returns nil.
+func (b *Builder) WriteByte(c byte) error {
+	b.data = append(b.data, c)
+	return nil
+}
+
+// Len returns the number of residues written so far.
+func (b *Builder) Len() int {
+	return len(b.data)
+}
+
+// Reset discards the residues written so far.
+func (b *Builder) Reset() {
+	b.data = nil
+}
+
+// Sequence returns the Sequence built. The Sequence takes over the buffer of the Builder, which is reset, so further writes start a new sequence with the same header.
+func (b *Builder) Sequence() *Sequence {
+	s := &Sequence{header: b.header, data: b.data,
+		lineLength: DefaultLineLength}
+	b.Reset()
+	return s
+}
+
+// size returns the number of bytes in the formatted record, the header line plus the residues plus one newline per data line.
+func (r *fastaReader) size() int64 {
+	n := len(r.s.data)
+	if n == 0 {
+		return int64(len(r.head))
+	}
+	lines := (n + r.l - 1) / r.l
+	return int64(len(r.head) + n + lines)
+}
+
+// Read reads the formatted record.
+func (r *fastaReader) Read(p []byte) (int, error) {
+	size := r.size()
+	if r.pos >= size {
+		return 0, io.EOF
+	}
+	n := 0
+	for n < len(p) && r.pos < size {
+		if r.pos < int64(len(r.head)) {
+			k := copy(p[n:], r.head[r.pos:])
+			n += k
+			r.pos += int64(k)
+			continue
+		}
+		off := r.pos - int64(len(r.head))
+		line, col := off/int64(r.l+1), int(off%int64(r.l+1))
+		start := int(line) * r.l
+		end := start + r.l
+		if end > len(r.s.data) {
+			end = len(r.s.data)
+		}
+		if start+col == end {
+			p[n] = '\n'
+			n++
+			r.pos++
+			continue
+		}
+		k := copy(p[n:], r.s.data[start+col:end])
+		n += k
+		r.pos += int64(k)
+	}
+	return n, nil
+}
+
+// Seek sets the position of the next Read.
+func (r *fastaReader) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size()
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// Clone returns a deep copy of the Sequence.
+func (s *Sequence) Clone() *Sequence {
+	c := *s
+	if s.data != nil {
+		c.data = make([]byte, len(s.data))
+		copy(c.data, s.data)
+	}
+	if s.lazy != nil {
+		l := *s.lazy
+		c.lazy = &l
+	}
+	c.meta = copyMeta(s.meta)
+	return &c
+}
+
+// Preview returns a one-line summary of the Sequence: its header, its length, and its first and last n residues separated by an ellipsis. Sequences of at most 2n residues are shown in full.
+func (s *Sequence) Preview(n int) string {
+	s.mustLoad()
+	if n < 0 {
+		n = 0
+	}
+	d := s.data
+	p := fmt.Sprintf(">%s (%s bp)", s.header,
+		formatThousands(len(d)))
+	if len(d) == 0 {
+		return p
+	}
+	if len(d) <= 2*n {
+		return p + " " + string(d)
+	}
+	return p + " " + string(d[:n]) + "…" + string(d[len(d)-n:])
+}
+
+// FormatWithRuler writes the Sequence to w with width residues per line, preceded by their position, and a ruler on top.
+func (s *Sequence) FormatWithRuler(w io.Writer, width int) error {
+	return s.FormatWithRulerGroups(w, width, 0)
+}
+
+// FormatWithRulerGroups is like FormatWithRuler, but separates groups of group residues by a blank. A group of zero means no grouping.
+func (s *Sequence) FormatWithRulerGroups(w io.Writer, width,
+	group int) error {
+	if width < 1 || group < 0 {
+		return fmt.Errorf("invalid width %d or group %d", width,
+			group)
+	}
+	if err := s.Materialize(); err != nil {
+		return err
+	}
+	d := s.Data()
+	pad := rulerPad(len(d))
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, ">%s\n", s.header)
+	at := func(c int) int {
+		if group > 0 {
+			return c + c/group
+		}
+		return c
+	}
+	n := at(width-1) + 1
+	nums := bytes.Repeat([]byte{' '}, n)
+	ticks := bytes.Repeat([]byte{' '}, n)
+	for c := 0; c < width; c++ {
+		ticks[at(c)] = '-'
+		if (c+1)%5 == 0 {
+			ticks[at(c)] = ':'
+		}
+		if (c+1)%10 == 0 {
+			ticks[at(c)] = '|'
+			l := strconv.Itoa(c + 1)
+			copy(nums[at(c)+1-len(l):], l)
+		}
+	}
+	margin := strings.Repeat(" ", pad+1)
+	fmt.Fprintf(bw, "%s%s\n", margin, bytes.TrimRight(nums, " "))
+	fmt.Fprintf(bw, "%s%s\n", margin, ticks)
+	for i := 0; i < len(d); i += width {
+		j := i + width
+		if j > len(d) {
+			j = len(d)
+		}
+		fmt.Fprintf(bw, "%*d ", pad, i+1)
+		for k, c := range d[i:j] {
+			if group > 0 && k > 0 && k%group == 0 {
+				bw.WriteByte(' ')
+			}
+			bw.WriteByte(c)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// SetMeta sets the metadata key to value.
+func (s *Sequence) SetMeta(key string, value interface{}) {
+	s.mustBeMutable()
+	if s.meta == nil {
+		s.meta = make(map[string]interface{})
+	}
+	s.meta[key] = value
+}
+
+// Meta returns the metadata value of key and whether it was set.
+func (s *Sequence) Meta(key string) (interface{}, bool) {
+	v, ok := s.meta[key]
+	return v, ok
+}
+
+// MetaKeys returns the metadata keys in sorted order.
+func (s *Sequence) MetaKeys() []string {
+	keys := make([]string, 0, len(s.meta))
+	for k := range s.meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HeaderWithMeta returns the header followed by the metadata as blank-separated key=value attributes in the order of their keys. Values are formatted with MetaFromHeader.
+func (s *Sequence) HeaderWithMeta() string {
+	var b strings.Builder
+	b.WriteString(s.header)
+	for _, k := range s.MetaKeys() {
+		fmt.Fprintf(&b, " %s=%v", k, s.meta[k])
+	}
+	return b.String()
+}
+
+// MetaFromHeader moves the key=value attributes in the description into the metadata, where their values are stored as strings. It returns the number of attributes moved.
+func (s *Sequence) MetaFromHeader() int {
+	s.mustBeMutable()
+	n := 0
+	var rest []string
+	for _, w := range strings.Fields(s.Description()) {
+		i := strings.IndexByte(w, '=')
+		if i < 1 {
+			rest = append(rest, w)
+			continue
+		}
+		s.SetMeta(w[:i], w[i+1:])
+		n++
+	}
+	if n > 0 {
+		s.header = composeHeader(s.ID(), strings.Join(rest, " "))
+		s.rawHeader = ""
+	}
+	return n
+}
+
+// Write writes s. The output is buffered until Flush is called.
+func (w *Writer) Write(s *Sequence) error {
+	return format(w.w, s, w.lineLength, w.rawHeaders)
+}
+
+// Flush writes any buffered output to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.w.Flush()
+}
+
+// WrapInconsistent reports whether the data lines of the sequence scanned last were of inconsistent length, in which case writing it doesn't reproduce the input. It is only meaningful with WithPreserveWrapping.
+func (s *Scanner) WrapInconsistent() bool {
+	return s.lastInconsistent
+}
+
+// Error describes the invalid residue.
+func (e *ErrInvalidResidue) Error() string {
+	return fmt.Sprintf("%q: invalid residue %q at line %d, "+
+		"offset %d", e.ID, e.Byte, e.Line, e.Offset)
+}
+
+// Is reports whether target is an ErrInvalidResidue, so that errors.Is(err, new(ErrInvalidResidue)) detects invalid residues regardless of their position.
+func (e *ErrInvalidResidue) Is(target error) bool {
+	_, ok := target.(*ErrInvalidResidue)
+	return ok
+}
+
+// Translate returns the translation of the Sequence in the first reading frame using the NCBI genetic code table, for example 1 for the standard code or 11 for bacteria. Ambiguous codons are translated to the amino acid shared by all their expansions, or to X. A trailing partial codon is ignored.
+func (s *Sequence) Translate(table int) (*Sequence, error) {
+	tt, err := translationTable(table)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Materialize(); err != nil {
+		return nil, err
+	}
+	p := make([]byte, len(s.data)/3)
+	for i := range p {
+		c := s.data[3*i : 3*i+3]
+		p[i] = tt[codonIndex(c)]
+	}
+	return s.derive(p), nil
+}
+
+// FindStartCodons returns the positions of the codons in starts on the forward strand in any frame, ignoring case. If starts is empty, ATG is used.
+func (s *Sequence) FindStartCodons(starts []string) []int {
+	if len(starts) == 0 {
+		starts = []string{"ATG"}
+	}
+	s.mustLoad()
+	var pos []int
+	for i := 0; i+3 <= len(s.data); i++ {
+		for _, c := range starts {
+			if strings.EqualFold(string(s.data[i:i+3]), c) {
+				pos = append(pos, i)
+				break
+			}
+		}
+	}
+	return pos
+}
+
+// FindStopCodons returns the positions of the stop codons of NCBI genetic code table in frame, which is 1, 2, or 3 on the forward strand, and -1, -2, or -3 on the reverse strand. Positions refer to the first residue of the codon on the forward strand and are sorted. Partial codons at the ends are ignored, and ambiguous codons count as stops only if all their expansions are stops. FindStopCodons panics on an unknown genetic code or frame.
+func (s *Sequence) FindStopCodons(table int, frame int) []int {
+	tt, err := translationTable(table)
+	if err != nil {
+		panic("fasta: " + err.Error())
+	}
+	if frame == 0 || frame < -3 || frame > 3 {
+		panic(fmt.Sprintf("fasta: invalid frame %d", frame))
+	}
+	s.mustLoad()
+	d := s.data
+	var pos []int
+	if frame > 0 {
+		for i := frame - 1; i+3 <= len(d); i += 3 {
+			if tt[codonIndex(d[i:i+3])] == '*' {
+				pos = append(pos, i)
+			}
+		}
+		return pos
+	}
+	initDic()
+	end := len(d) + frame + 1
+	c := make([]byte, 3)
+	for i := end % 3; i+3 <= end; i += 3 {
+		c[0], c[1], c[2] = dic[d[i+2]], dic[d[i+1]], dic[d[i]]
+		if tt[codonIndex(c)] == '*' {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
+
+// ValidateCDS checks that the Sequence is a complete coding sequence in NCBI genetic code table: its length is a multiple of three, it begins with a start codon, and it ends with the only stop codon in frame. The error lists all problems found, including the positions of internal stops.
+func (s *Sequence) ValidateCDS(table int) error {
+	if _, err := translationTable(table); err != nil {
+		return err
+	}
+	if err := s.Materialize(); err != nil {
+		return err
+	}
+	n := s.Length()
+	if n%3 != 0 {
+		return fmt.Errorf("%q: length %d not a multiple of three",
+			s.header, n)
+	}
+	var probs []string
+	starts := []string{"ATG"}
+	if table == 11 {
+		starts = append(starts, "GTG", "TTG")
+	}
+	if p := s.FindStartCodons(starts); len(p) == 0 || p[0] != 0 {
+		first := ""
+		if n >= 3 {
+			first = string(s.data[:3])
+		}
+		probs = append(probs, fmt.Sprintf("no start codon, but %q",
+			first))
+	}
+	stops := s.FindStopCodons(table, 1)
+	if len(stops) == 0 || stops[len(stops)-1] != n-3 {
+		probs = append(probs, "no stop codon at end")
+	} else {
+		stops = stops[:len(stops)-1]
+	}
+	if len(stops) > 0 {
+		ps := make([]string, len(stops))
+		for i, p := range stops {
+			ps[i] = strconv.Itoa(p)
+		}
+		probs = append(probs, "internal stop codons at "+
+			strings.Join(ps, ", "))
+	}
+	if len(probs) > 0 {
+		return fmt.Errorf("%q: %s", s.header,
+			strings.Join(probs, "; "))
+	}
+	return nil
+}
+
+// CAI returns the codon adaptation index of the Sequence in NCBI genetic code table given the codon weights in reference, as computed by CodonWeights. Ambiguous codons are excluded, use CAICount to find out how many.
+func (s *Sequence) CAI(reference map[string]float64,
+	table int) (float64, error) {
+	cai, _, _, err := s.CAICount(reference, table)
+	return cai, err
+}
+
+// CAICount is like CAI, but also returns the number of codons used and the number of ambiguous codons excluded. It returns an error if the length of the Sequence isn't a multiple of three, a weighted codon is missing from reference, or no codon can be scored.
+func (s *Sequence) CAICount(reference map[string]float64,
+	table int) (cai float64, used, excluded int, err error) {
+	if _, ok := geneticCodes[table]; !ok {
+		return 0, 0, 0, fmt.Errorf("unknown genetic code %d", table)
+	}
+	if err := s.Materialize(); err != nil {
+		return 0, 0, 0, err
+	}
+	if len(s.data)%3 != 0 {
+		return 0, 0, 0, fmt.Errorf("%q: length %d not a "+
+			"multiple of three", s.header, len(s.data))
+	}
+	weighted := make(map[string]bool)
+	for _, codons := range synonymousCodons(table) {
+		for _, c := range codons {
+			weighted[c] = true
+		}
+	}
+	sum := 0.0
+	for i := 0; i < len(s.data); i += 3 {
+		c, ok := concreteCodon(s.data[i : i+3])
+		if !ok {
+			excluded++
+			continue
+		}
+		if !weighted[c] {
+			continue
+		}
+		w, ok := reference[c]
+		if !ok || w <= 0 {
+			return 0, 0, 0, fmt.Errorf("no positive weight for codon %q", c)
+		}
+		sum += math.Log(w)
+		used++
+	}
+	if used == 0 {
+		return 0, 0, excluded, fmt.Errorf("%q: no codons to "+
+			"score", s.header)
+	}
+	return math.Exp(sum / float64(used)), used, excluded, nil
+}
+
+// CumulativeGCSkew returns the cumulative GC skew at every position of the Sequence, where G counts +1, C counts -1, and all other characters count zero, regardless of case.
+func (s *Sequence) CumulativeGCSkew() []float64 {
+	return s.CumulativeGCSkewStride(1)
+}
+
+// CumulativeGCSkewStride returns the cumulative GC skew at every stride-th position, that is, after stride, 2stride, ... residues, and at the end of the sequence. It panics if stride isn't positive.
+func (s *Sequence) CumulativeGCSkewStride(stride int) []float64 {
+	if stride <= 0 {
+		panic("fasta: stride must be positive")
+	}
+	s.mustLoad()
+	n := len(s.data)
+	skew := make([]float64, 0, (n+stride-1)/stride)
+	sum := 0
+	for i, c := range s.data {
+		sum += gcSkew(c)
+		if (i+1)%stride == 0 || i == n-1 {
+			skew = append(skew, float64(sum))
+		}
+	}
+	return skew
+}
+
+// PredictOriTer predicts the origin and terminus of replication of a circular bacterial chromosome as the positions of the minimum and maximum of its cumulative GC skew. The skew curve is first detrended by the average skew per residue, which makes the prediction invariant to rotating the sequence, up to ties, where the first position wins. Positions are zero-based.
+func (s *Sequence) PredictOriTer() (ori, ter int) {
+	s.mustLoad()
+	n := int64(len(s.data))
+	total := int64(0)
+	for _, c := range s.data {
+		total += int64(gcSkew(c))
+	}
+	var sum, min, max int64
+	for i, c := range s.data {
+		sum += int64(gcSkew(c))
+		v := n*sum - int64(i+1)*total
+		if i == 0 || v < min {
+			min, ori = v, i
+		}
+		if i == 0 || v > max {
+			max, ter = v, i
+		}
+	}
+	return ori, ter
+}
+
+// FindTandemRepeats returns the perfect tandem repeats with unit lengths between minUnit and maxUnit and at least minCopies complete copies, sorted by start and unit length. Residues are compared regardless of case, and ambiguous residues like N never match. Trailing partial copies aren't included. A repeat is reported once, with the unit it starts with. FindTandemRepeats panics if minUnit isn't positive, maxUnit is less than minUnit, or minCopies is less than two.
+func (s *Sequence) FindTandemRepeats(minUnit, maxUnit,
+	minCopies int) []TandemRepeat {
+	if minUnit < 1 || maxUnit < minUnit || minCopies < 2 {
+		panic("fasta: invalid unit lengths or copy number")
+	}
+	s.mustLoad()
+	var reps []TandemRepeat
+	for p := minUnit; p <= maxUnit; p++ {
+		reps = append(reps, tandemRepeats(s.data, p, minCopies)...)
+	}
+	sortRepeats(reps)
+	return reps
+}
+
+// FindPalindromes returns the perfect palindromes with lengths between minLen and maxLen. It is FindNearPalindromes without spacer.
+func (s *Sequence) FindPalindromes(minLen, maxLen int) []Palindrome {
+	return s.FindNearPalindromes(minLen, maxLen, 0)
+}
+
+// FindNearPalindromes returns the palindromes whose paired arms have a total length between minLen and maxLen, and whose spacer is at most maxSpacer residues long. For each center and spacer, the longest palindrome is reported; if it exceeds maxLen, it is truncated to maxLen around its center. Palindromes contained in others with no longer spacer are omitted, but palindromes may overlap. Ambiguous residues like N never pair, so palindromes don't extend across them. The palindromes are sorted by start, length, and spacer. FindNearPalindromes panics if minLen is less than two, maxLen is less than minLen, or maxSpacer is negative.
+func (s *Sequence) FindNearPalindromes(minLen, maxLen,
+	maxSpacer int) []Palindrome {
+	if minLen < 2 || maxLen < minLen || maxSpacer < 0 {
+		panic("fasta: invalid palindrome lengths or spacer")
+	}
+	s.mustLoad()
+	initDic()
+	d := s.data
+	minArm, maxArm := (minLen+1)/2, maxLen/2
+	var pals []Palindrome
+	for c := 0; c <= len(d); c++ {
+		for g := 0; g <= maxSpacer && c+g <= len(d); g++ {
+			a := 0
+			for a < maxArm && c-a-1 >= 0 && c+g+a < len(d) &&
+				sameNuc(dic[d[c-a-1]], d[c+g+a]) {
+				a++
+			}
+			if a >= minArm {
+				pals = append(pals, Palindrome{Start: c - a,
+					Length: 2*a + g, Spacer: g})
+			}
+		}
+	}
+	sort.Slice(pals, func(i, j int) bool {
+		p, q := pals[i], pals[j]
+		if p.Start != q.Start {
+			return p.Start < q.Start
+		}
+		if p.Length != q.Length {
+			return p.Length > q.Length
+		}
+		return p.Spacer < q.Spacer
+	})
+	var kept []Palindrome
+	for _, p := range pals {
+		contained := false
+		for j := len(kept) - 1; j >= 0; j-- {
+			q := kept[j]
+			if q.Start < p.Start-maxLen-maxSpacer {
+				break
+			}
+			if q.Start+q.Length >= p.Start+p.Length &&
+				q.Spacer <= p.Spacer {
+				contained = true
+				break
+			}
+		}
+		if !contained {
+			kept = append(kept, p)
+		}
+	}
+	pals = kept
+	sort.Slice(pals, func(i, j int) bool {
+		p, q := pals[i], pals[j]
+		if p.Start != q.Start {
+			return p.Start < q.Start
+		}
+		if p.Length != q.Length {
+			return p.Length < q.Length
+		}
+		return p.Spacer < q.Spacer
+	})
+	return pals
+}
+
+// TelomereContent returns the fractions of the first and last window residues of the Sequence that lie in tandem repeats of at least two copies of the telomere unit, whose reverse complement is searched at the 5' end. An empty unit means TTAGGG. Repeats may start anywhere in the unit and are compared regardless of case. If the sequence is shorter than window, both ends are the whole sequence. Ambiguous residues like N never count as telomeric, so a window of only N has a content of zero. TelomereContent panics if window isn't positive.
+func (s *Sequence) TelomereContent(unit string,
+	window int) (fivePrime, threePrime float64) {
+	if window <= 0 {
+		panic("fasta: window must be positive")
+	}
+	if unit == "" {
+		unit = "TTAGGG"
+	}
+	s.mustLoad()
+	initDic()
+	rc := []byte(unit)
+	for i, j := 0, len(rc)-1; i < j; i, j = i+1, j-1 {
+		rc[i], rc[j] = rc[j], rc[i]
+	}
+	for i, c := range rc {
+		rc[i] = dic[c]
+	}
+	d := s.data
+	if window > len(d) {
+		window = len(d)
+	}
+	if window == 0 {
+		return 0, 0
+	}
+	w := float64(window)
+	fivePrime = float64(repeatCoverage(d[:window], string(rc))) / w
+	threePrime = float64(repeatCoverage(d[len(d)-window:],
+		unit)) / w
+	return fivePrime, threePrime
+}
+
+// Sketch returns the MinHash sketch of the canonical k-mers of the Sequence of length k with at most size hashes, using DefaultSketchSeed.
+func (s *Sequence) Sketch(k, size int) *MinHashSketch {
+	return NewSketch([]*Sequence{s}, k, size, DefaultSketchSeed)
+}
+
+// Len returns the number of hashes in the sketch, which is less than its size for sequences with few k-mers.
+func (a *MinHashSketch) Len() int {
+	return len(a.hashes)
+}
+
+// Jaccard returns the estimated Jaccard index of the k-mer sets of a and b. It is zero if both sketches are empty, and it panics if the sketches differ in k-mer length or seed.
+func (a *MinHashSketch) Jaccard(b *MinHashSketch) float64 {
+	if a.k != b.k || a.seed != b.seed {
+		panic("fasta: comparing incompatible sketches")
+	}
+	n := a.size
+	if b.size < n {
+		n = b.size
+	}
+	x, y := a.hashes, b.hashes
+	i, j, union, shared := 0, 0, 0, 0
+	for union < n && (i < len(x) || j < len(y)) {
+		switch {
+		case j == len(y) || i < len(x) && x[i] < y[j]:
+			i++
+		case i == len(x) || y[j] < x[i]:
+			j++
+		default:
+			i++
+			j++
+			shared++
+		}
+		union++
+	}
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
+
+// Distance returns the Mash distance between a and b.
+func (a *MinHashSketch) Distance(b *MinHashSketch) float64 {
+	j := a.Jaccard(b)
+	if j == 0 {
+		return 1
+	}
+	return -math.Log(2*j/(1+j)) / float64(a.k)
+}
+
+// MarshalBinary encodes the sketch as bytes.
+func (a *MinHashSketch) MarshalBinary() ([]byte, error) {
+	b := []byte(sketchMagic)
+	v := make([]byte, binary.MaxVarintLen64)
+	put := func(x uint64) {
+		n := binary.PutUvarint(v, x)
+		b = append(b, v[:n]...)
+	}
+	put(uint64(a.k))
+	put(uint64(a.size))
+	put(a.seed)
+	put(uint64(len(a.hashes)))
+	prev := uint64(0)
+	for _, h := range a.hashes {
+		put(h - prev)
+		prev = h
+	}
+	return b, nil
+}
+
+// UnmarshalBinary decodes a sketch encoded by MarshalBinary into a.
+func (a *MinHashSketch) UnmarshalBinary(b []byte) error {
+	if !bytes.HasPrefix(b, []byte(sketchMagic)) {
+		return errors.New("not a sketch")
+	}
+	b = b[len(sketchMagic):]
+	next := func() uint64 {
+		x, n := binary.Uvarint(b)
+		if n <= 0 {
+			b = nil
+			return 0
+		}
+		b = b[n:]
+		return x
+	}
+	var v [4]uint64
+	for i := range v {
+		if v[i] = next(); b == nil {
+			return errors.New("truncated sketch")
+		}
+	}
+	if v[0] < 1 || v[0] > 32 || v[1] < 1 || v[3] > v[1] ||
+		v[3] > uint64(len(b)) {
+		return errors.New("invalid sketch")
+	}
+	hashes := make([]uint64, v[3])
+	prev := uint64(0)
+	for i := range hashes {
+		d := next()
+		if b == nil {
+			return errors.New("truncated sketch")
+		}
+		prev += d
+		hashes[i] = prev
+	}
+	a.k, a.size, a.seed, a.hashes = int(v[0]), int(v[1]), v[2], hashes
+	return nil
+}
+
+// KmerJaccard returns the Jaccard index of the sets of canonical k-mers of length k in the Sequence and other, that is, the number of k-mers in both divided by the number of k-mers in either. K-mers containing residues other than A, C, G, and T are excluded. If neither sequence has a k-mer, the index is zero. It returns an error if k isn't between 1 and 32.
+func (s *Sequence) KmerJaccard(other *Sequence, k int) (float64, error) {
+	if k < 1 || k > 32 {
+		return 0, fmt.Errorf("k-mer length %d not in [1, 32]", k)
+	}
+	if err := s.Materialize(); err != nil {
+		return 0, err
+	}
+	if err := other.Materialize(); err != nil {
+		return 0, err
+	}
+	a := make(map[uint64]uint32)
+	b := make(map[uint64]uint32)
+	countKmers(s.data, k, a)
+	countKmers(other.data, k, b)
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	shared := 0
+	for x := range a {
+		if _, ok := b[x]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0, nil
+	}
+	return float64(shared) / float64(union), nil
+}
+func (l *lockedSource) Int63() int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Int63()
+}
+func (l *lockedSource) Uint64() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.src.Uint64()
+}
+func (l *lockedSource) Seed(seed int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.src.Seed(seed)
+}
+
+// derive returns a new Sequence with data d and the header, line length, and metadata of s.
+func (s *Sequence) derive(d []byte) *Sequence {
+	return &Sequence{header: s.header, rawHeader: s.rawHeader,
+		data: d, lineLength: s.lineLength, meta: copyMeta(s.meta)}
+}
+
+// Reversed returns a reversed copy of the Sequence.
+func (s *Sequence) Reversed() *Sequence {
+	s.mustLoad()
+	n := len(s.data)
+	d := make([]byte, n)
+	for i, c := range s.data {
+		d[n-1-i] = c
+	}
+	return s.derive(d)
+}
+
+// Complemented returns a complemented copy of the Sequence.
+func (s *Sequence) Complemented() *Sequence {
+	s.mustLoad()
+	initDic()
+	d := make([]byte, len(s.data))
+	for i, c := range s.data {
+		d[i] = dic[c]
+	}
+	return s.derive(d)
+}
+
+// ReverseComplemented returns a reverse-complemented copy of the Sequence.
+func (s *Sequence) ReverseComplemented() *Sequence {
+	s.mustLoad()
+	initDic()
+	n := len(s.data)
+	d := make([]byte, n)
+	for i := range d {
+		d[i] = dic[s.data[n-1-i]]
+	}
+	return s.derive(d)
+}
+
+// Upper returns a copy of the Sequence with its residues in upper case, which removes soft-masking.
+func (s *Sequence) Upper() *Sequence {
+	s.mustLoad()
+	d := make([]byte, len(s.data))
+	for i, c := range s.data {
+		d[i] = upper(c)
+	}
+	return s.derive(d)
+}
+
+// Freeze makes the Sequence read-only. Afterwards, methods that change it, like SetData, SetHeader, Complement, Shuffle, or SetMeta, panic, and MaskBED and RenameFromMap return an error wrapping ErrFrozen. The slice returned by Data must not be modified either, which can't be enforced; use DataCopy instead. Clone returns a frozen copy of a frozen sequence, Thaw a mutable one.
+func (s *Sequence) Freeze() {
+	s.mustLoad()
+	s.Hash64()
+	s.frozen = true
+}
+
+// Frozen reports whether the Sequence is frozen.
+func (s *Sequence) Frozen() bool {
+	return s.frozen
+}
+
+// Thaw returns a mutable deep copy of the Sequence, which stays frozen.
+func (s *Sequence) Thaw() *Sequence {
+	c := s.Clone()
+	c.frozen = false
+	return c
+}
+
+// mustBeMutable panics if the Sequence is frozen.
+func (s *Sequence) mustBeMutable() {
+	if s.frozen {
+		panic(fmt.Sprintf("fasta: changing frozen sequence %q",
+			s.header))
+	}
+}
+
+// Error returns the URL and the status.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s: %s", e.URL, e.Status)
+}
+
+// Error lists the missing accessions.
+func (e *MissingAccessionsError) Error() string {
+	return "accessions not found: " +
+		strings.Join(e.Accessions, ", ")
+}
+
+// RawHeader returns the header as found in the input if the Sequence was scanned with WithRawHeaders, and the empty string otherwise. Changing the header clears it.
+func (s *Sequence) RawHeader() string {
+	return s.rawHeader
+}
+
+// Count returns the number of residues in the Sequence that are among residues. Case matters.
+func (s *Sequence) Count(residues ...byte) int {
+	return s.countTable(residueTable(residues))
+}
+
+// CountFold is like Count but ignores case.
+func (s *Sequence) CountFold(residues ...byte) int {
+	var t [256]bool
+	for _, r := range residues {
+		t[r] = true
+		if r >= 'a' && r <= 'z' {
+			t[r-'a'+'A'] = true
+		} else if r >= 'A' && r <= 'Z' {
+			t[r-'A'+'a'] = true
+		}
+	}
+	return s.countTable(&t)
+}
+
+// countTable returns the number of residues marked in t.
+func (s *Sequence) countTable(t *[256]bool) int {
+	s.mustLoad()
+	return countIn(s.data, t)
+}
+
+// Fraction returns the fraction of residues in the Sequence that are among residues, or 0 for an empty Sequence. Case matters.
+func (s *Sequence) Fraction(residues ...byte) float64 {
+	n := s.Count(residues...)
+	if n == 0 {
+		return 0
+	}
+	return float64(n) / float64(len(s.data))
+}
+
+// Positions returns the zero-based positions of residue in the Sequence in ascending order, or nil if there are none. Case matters.
+func (s *Sequence) Positions(residue byte) []int {
+	s.mustLoad()
+	var pos []int
+	d := s.data
+	for o := 0; ; {
+		i := bytes.IndexByte(d[o:], residue)
+		if i < 0 {
+			return pos
+		}
+		pos = append(pos, o+i)
+		o += i + 1
+	}
+}
+
+// PositionsFold is like Positions but ignores case.
+func (s *Sequence) PositionsFold(residue byte) []int {
+	lo, up := residue, residue
+	if residue >= 'a' && residue <= 'z' {
+		up = residue - 'a' + 'A'
+	} else if residue >= 'A' && residue <= 'Z' {
+		lo = residue - 'A' + 'a'
+	}
+	if lo == up {
+		return s.Positions(residue)
+	}
+	return s.PositionsOf(func(c byte) bool {
+		return c == lo || c == up
+	})
+}
+
+// PositionsOf returns the zero-based positions of the residues in the Sequence for which class returns true, in ascending order, or nil if there are none. For example, IsAmbiguous selects the ambiguous nucleotides.
+func (s *Sequence) PositionsOf(class func(byte) bool) []int {
+	s.mustLoad()
+	var pos []int
+	for i, c := range s.data {
+		if class(c) {
+			pos = append(pos, i)
+		}
+	}
+	return pos
+}
+
+// Replace replaces every occurrence of old in the Sequence by new and returns the number of replacements. Case matters.
+func (s *Sequence) Replace(old, new byte) int {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	d := s.data
+	n := 0
+	for o := 0; ; {
+		i := bytes.IndexByte(d[o:], old)
+		if i < 0 {
+			return n
+		}
+		d[o+i] = new
+		n++
+		o += i + 1
+	}
+}
+
+// ReplaceFold is like Replace but replaces old in either case by new.
+func (s *Sequence) ReplaceFold(old, new byte) int {
+	lo, up := old, old
+	if old >= 'a' && old <= 'z' {
+		up = old - 'a' + 'A'
+	} else if old >= 'A' && old <= 'Z' {
+		lo = old - 'A' + 'a'
+	}
+	if lo == up {
+		return s.Replace(old, new)
+	}
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	n := 0
+	for i, c := range s.data {
+		if c == lo || c == up {
+			s.data[i] = new
+			n++
+		}
+	}
+	return n
+}
+
+// ReplaceFunc replaces every residue c in the Sequence by f(c).
+func (s *Sequence) ReplaceFunc(f func(byte) byte) {
+	s.mustBeMutable()
+	s.mustLoad()
+	s.dataChanged()
+	var t [256]byte
+	for i := range t {
+		t[i] = f(byte(i))
+	}
+	for i, c := range s.data {
+		s.data[i] = t[c]
+	}
+}
+
+// ExpandIUPAC returns the concrete sequences of A, C, G, and T consistent with the IUPAC codes in the Sequence, in lexicographic order. Each residue keeps its case, U counts as T. The ID in the header of the i-th result is suffixed by an underscore and i, counting from 1. If there would be more than limit results, the error wrapping ErrExpansionLimit gives their number.
+func (s *Sequence) ExpandIUPAC(limit int) ([]*Sequence, error) {
+	if err := s.Materialize(); err != nil {
+		return nil, err
+	}
+	choices := make([]string, len(s.data))
+	for i, c := range s.data {
+		m := nucMask(c)
+		if m == 0 {
+			return nil, fmt.Errorf("%q: residue %q at %d isn't a "+
+				"nucleotide", s.ID(), c, i)
+		}
+		b := make([]byte, 0, 4)
+		for _, n := range []byte("ACGT") {
+			if m&nucMask(n) != 0 {
+				if c >= 'a' && c <= 'z' {
+					n += 'a' - 'A'
+				}
+				b = append(b, n)
+			}
+		}
+		choices[i] = string(b)
+	}
+	total := big.NewInt(1)
+	for _, c := range choices {
+		total.Mul(total, big.NewInt(int64(len(c))))
+	}
+	if total.Cmp(big.NewInt(int64(limit))) > 0 {
+		return nil, fmt.Errorf("%q expands to %s sequences, more than "+
+			"%d: %w", s.ID(), total, limit, ErrExpansionLimit)
+	}
+	n := int(total.Int64())
+	seqs := make([]*Sequence, 0, n)
+	odo := make([]int, len(choices))
+	id, desc := s.ID(), s.Description()
+	for k := 1; k <= n; k++ {
+		d := make([]byte, len(choices))
+		for i, c := range choices {
+			d[i] = c[odo[i]]
+		}
+		h := composeHeader(id+"_"+strconv.Itoa(k), desc)
+		seqs = append(seqs, &Sequence{header: h, data: d,
+			lineLength: s.lineLength})
+		for i := len(odo) - 1; i >= 0; i-- {
+			odo[i]++
+			if odo[i] < len(choices[i]) {
+				break
+			}
+			odo[i] = 0
+		}
+	}
+	return seqs, nil
+}
+
+// At returns the residue at zero-based position i. It doesn't panic; an index outside the Sequence is an error wrapping ErrOutOfRange, and so is failing to load the data of a lazy Sequence.
+func (s *Sequence) At(i int) (byte, error) {
+	if err := s.Materialize(); err != nil {
+		return 0, err
+	}
+	if i < 0 || i >= len(s.data) {
+		return 0, fmt.Errorf("%q: index %d, length %d: %w",
+			s.header, i, len(s.data), ErrOutOfRange)
+	}
+	return s.data[i], nil
+}
+
+// Set sets the residue at zero-based position i to b. Like At, it doesn't panic. Setting a residue of a frozen Sequence is an error wrapping ErrFrozen.
+func (s *Sequence) Set(i int, b byte) error {
+	if s.frozen {
+		return fmt.Errorf("%q: %w", s.header, ErrFrozen)
+	}
+	_, err := s.At(i)
+	if err != nil {
+		return err
+	}
+	s.data[i] = b
+	s.dataChanged()
+	return nil
+}
+
+// AtUnsafe returns the residue at zero-based position i without checking i, for use in hot loops. It panics if i is outside the Sequence.
+func (s *Sequence) AtUnsafe(i int) byte {
+	s.mustLoad()
+	return s.data[i]
+}
+
+// Codons calls f with each codon of the forward strand in frame 0, 1, or 2, and its zero-based start, in ascending order. A trailing partial codon is skipped. The iteration stops when f returns false. Codons panics on any other frame.
+func (s *Sequence) Codons(frame int, f func(int, [3]byte) bool) {
+	s.mustLoad()
+	if frame < 0 || frame > 2 {
+		panic(fmt.Sprintf("fasta: invalid frame %d", frame))
+	}
+	d := s.data
+	for i := frame; i+3 <= len(d); i += 3 {
+		if !f(i, [3]byte{d[i], d[i+1], d[i+2]}) {
+			return
+		}
+	}
+}
+
+// CodonsReverse is like Codons, but iterates over the reverse complement in transcription order, that is, in descending order of start. The start of a codon refers to the forward strand, where it occupies the three residues from its start onward. Frame 0 begins with the last residue of the Sequence.
+func (s *Sequence) CodonsReverse(frame int, f func(int, [3]byte) bool) {
+	s.mustLoad()
+	if frame < 0 || frame > 2 {
+		panic(fmt.Sprintf("fasta: invalid frame %d", frame))
+	}
+	initDic()
+	d := s.data
+	for i := len(d) - frame - 3; i >= 0; i -= 3 {
+		c := [3]byte{dic[d[i+2]], dic[d[i+1]], dic[d[i]]}
+		if !f(i, c) {
+			return
+		}
+	}
+}
+
+// WindowsIter calls f with the zero-based start and the residues of each window of length size that starts every step residues along the Sequence. The residues alias the data of the Sequence and are only valid during the call. If partial is set, the last window may be shorter than size, otherwise windows shorter than size are skipped. As in Windows, the iteration ends with the first window that reaches the end of the Sequence, or when f returns false. WindowsIter panics if size or step is not positive.
+func (s *Sequence) WindowsIter(size, step int, partial bool,
+	f func(int, []byte) bool) {
+	if size <= 0 || step <= 0 {
+		panic("fasta: window size and step must be positive")
+	}
+	s.mustLoad()
+	n := len(s.data)
+	for start := 0; start < n; start += step {
+		end := start + size
+		if end > n {
+			if !partial {
+				return
+			}
+			end = n
+		}
+		if !f(start, s.data[start:end:end]) || end == n {
+			return
+		}
+	}
+}
+
+// WindowSequences is like WindowsIter, but passes each window as a new Sequence named as in Windows, which may be kept.
+func (s *Sequence) WindowSequences(size, step int, partial bool,
+	f func(int, *Sequence) bool) {
+	s.WindowsIter(size, step, partial, func(i int, d []byte) bool {
+		h := regionHeader(s, i, i+len(d))
+		win := NewSequence(h, d)
+		win.meta = copyMeta(s.meta)
+		return f(i, win)
+	})
+}
+
+// SoftMaskedSegments returns each maximal run of at least minLen lowercase residues as a new Sequence named id:start-end, as in Windows. Runs separated by a single uppercase residue are separate segments.
+func (s *Sequence) SoftMaskedSegments(minLen int) []*Sequence {
+	return s.segments(minLen, true)
+}
+
+// UnmaskedSegments is the complement of SoftMaskedSegments; it returns the maximal runs of residues that aren't lowercase.
+func (s *Sequence) UnmaskedSegments(minLen int) []*Sequence {
+	return s.segments(minLen, false)
+}
+
+// segments returns the maximal runs of at least minLen residues that are lowercase if lower is set, and aren't otherwise.
+func (s *Sequence) segments(minLen int, lower bool) []*Sequence {
+	s.mustLoad()
+	var segs []*Sequence
+	d := s.data
+	for i := 0; i < len(d); {
+		if (d[i] >= 'a' && d[i] <= 'z') != lower {
+			i++
+			continue
+		}
+		j := i + 1
+		for j < len(d) && (d[j] >= 'a' && d[j] <= 'z') == lower {
+			j++
+		}
+		if j-i >= minLen {
+			h := regionHeader(s, i, j)
+			seg := NewSequence(h, d[i:j])
+			seg.meta = copyMeta(s.meta)
+			segs = append(segs, seg)
+		}
+		i = j
+	}
+	return segs
+}
+
+// LowercaseCount returns the number of residues between a and z.
+func (s *Sequence) LowercaseCount() int {
+	t := s.Counts()
+	return int(countLower(&t))
+}
+
+// LowercaseFraction returns the fraction of residues between a and z, or 0 for an empty Sequence.
+func (s *Sequence) LowercaseFraction() float64 {
+	n := s.LowercaseCount()
+	if n == 0 {
+		return 0
+	}
+	return float64(n) / float64(len(s.data))
+}
+
+// OK reports whether the FASTA file matched its manifest.
+func (v VerifyReport) OK() bool {
+	return len(v.Missing)+len(v.Extra)+len(v.Mismatched) == 0
+}
+
+// FetchRegion returns the residues of region, written as for ParseRegion. If the region can be split in several ways, the longest name found in the index is used, so that HLA-DRB1*13:01:01 refers to that whole sequence if it is indexed.
+func (f *Faidx) FetchRegion(region string) (*Sequence, error) {
+	name, start, end, err := f.resolveRegion(region)
+	if err != nil {
+		return nil, err
+	}
+	return f.Fetch(name, start, end)
+}
+
+// resolveRegion splits region into the longest indexed name and the zero-based, half-open interval, with open ends resolved.
+func (f *Faidx) resolveRegion(region string) (string, int, int,
+	error) {
+	name, start, end := region, 0, -1
+	_, ok := f.index[region]
+	for i := len(region); !ok; {
+		i = strings.LastIndexByte(region[:i], ':')
+		if i < 0 {
+			return "", 0, 0, fmt.Errorf("region %q: %w", region,
+				ErrUnknownSequence)
+		}
+		var err error
+		start, end, err = parseRange(region[i+1:])
+		if err == nil {
+			name = region[:i]
+			_, ok = f.index[name]
+		}
+	}
+	if end == -1 {
+		end = f.entries[f.index[name]].Length
+	}
+	return name, start, end, nil
+}
+
+// Error returns the region and its error.
+func (e RegionError) Error() string {
+	return fmt.Sprintf("region %d, %q: %v", e.Index, e.Region, e.Err)
+}
+
+// Error returns the number of failed regions and the first error.
+func (e FetchManyError) Error() string {
+	return fmt.Sprintf("%d regions failed, first: %v", len(e), e[0])
+}
+
+// FetchMany returns the residues of each of the regions, written as for ParseRegion, in the order given. The regions are read in the order of their offsets in the file. Regions that can't be fetched are nil in the result and are listed in the returned FetchManyError.
+func (f *Faidx) FetchMany(regions []string) ([]*Sequence, error) {
+	type job struct {
+		i, start, end int
+		name          string
+		off           int64
+	}
+	seqs := make([]*Sequence, len(regions))
+	var errs FetchManyError
+	var jobs []job
+	for i, r := range regions {
+		name, start, end, err := f.resolveRegion(r)
+		if err != nil {
+			errs = append(errs, RegionError{i, r, err})
+			continue
+		}
+		e := f.entries[f.index[name]]
+		jobs = append(jobs, job{i, start, end, name,
+			e.position(start)})
+	}
+	sort.SliceStable(jobs, func(a, b int) bool {
+		return jobs[a].off < jobs[b].off
+	})
+	for _, j := range jobs {
+		s, err := f.Fetch(j.name, j.start, j.end)
+		if err != nil {
+			errs = append(errs, RegionError{j.i, regions[j.i], err})
+			continue
+		}
+		seqs[j.i] = s
+	}
+	if len(errs) == 0 {
+		return seqs, nil
+	}
+	sort.Slice(errs, func(a, b int) bool {
+		return errs[a].Index < errs[b].Index
+	})
+	return seqs, errs
+}
+
+// ExtractBED is like the function ExtractBED, except that the intervals are fetched from the indexed file. As the index doesn't keep descriptions, the extracted sequences have none.
+func (f *Faidx) ExtractBED(bed io.Reader) ([]*Sequence, error) {
+	return f.extractBED(bed, false)
+}
+
+// ExtractBEDClamped is like ExtractBED, except that intervals reaching past the end of their sequence are clamped to it.
+func (f *Faidx) ExtractBEDClamped(bed io.Reader) ([]*Sequence, error) {
+	return f.extractBED(bed, true)
+}
+
+// extractBED implements the methods ExtractBED and ExtractBEDClamped.
+func (f *Faidx) extractBED(bed io.Reader,
+	clamp bool) ([]*Sequence, error) {
+	recs, err := readBED(bed)
+	if err != nil {
+		return nil, err
+	}
+	var out []*Sequence
+	for _, rec := range recs {
+		i, ok := f.index[rec.chrom]
+		if !ok {
+			return nil, fmt.Errorf("bed line %d: %w %q",
+				rec.line, ErrUnknownSequence, rec.chrom)
+		}
+		length := f.entries[i].Length
+		name := rec.name
+		if name == "" {
+			name = fmt.Sprintf("%s:%d-%d", rec.chrom, rec.start, rec.end)
+		}
+		start, end := rec.start, rec.end
+		if end > length {
+			if !clamp {
+				return nil, fmt.Errorf("bed line %d: interval %d-%d "+
+					"exceeds length %d of %q", rec.line, start, end,
+					length, rec.chrom)
+			}
+			end = length
+			if start > end {
+				start = end
+			}
+		}
+		x, err := f.Fetch(rec.chrom, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("bed line %d: %w", rec.line, err)
+		}
+		x.SetHeader(name)
+		if rec.strand == '-' {
+			x.ReverseComplement()
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+// Hash64 returns the 64-bit FNV-1a hash of the residues of the Sequence. The hash is cached and recomputed only after the data has been changed by one of the methods of Sequence. Changes made through the slice returned by Data go unnoticed, so call SetData afterwards.
+func (s *Sequence) Hash64() uint64 {
+	if !s.hashed {
+		s.mustLoad()
+		s.hash = hashData(s.data, false)
+		s.hashed = true
+	}
+	return s.hash
+}
+
+// dataChanged invalidates the cached content hash.
+func (s *Sequence) dataChanged() {
+	s.hashed = false
+}
+
+// EqualData returns true if the two sequences have the same residues, regardless of their headers. A nil Sequence equals only another nil Sequence.
+func (a *Sequence) EqualData(b *Sequence) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Length() != b.Length() {
+		return false
+	}
+	a.mustLoad()
+	b.mustLoad()
+	if a.frozen && b.frozen && a.hash != b.hash {
+		return false
+	}
+	return bytes.Equal(a.data, b.data)
+}
+
+// View returns a View of the residues from zero-based position start up to but excluding end. A region outside the Sequence is an error wrapping ErrOutOfRange, and so is failing to load the data of a lazy Sequence.
+func (s *Sequence) View(start, end int) (View, error) {
+	if err := s.Materialize(); err != nil {
+		return View{}, err
+	}
+	if start < 0 || end < start || end > len(s.data) {
+		return View{}, fmt.Errorf("%q: region %d-%d, length %d: %w",
+			s.header, start, end, len(s.data), ErrOutOfRange)
+	}
+	return View{s, start, end, s.data[start:end:end]}, nil
+}
+
+// Length returns the number of residues in the View.
+func (v View) Length() int {
+	return len(v.data)
+}
+
+// At returns the residue at zero-based position i of the View. Like Sequence.At, it doesn't panic; an index outside the View is an error wrapping ErrOutOfRange.
+func (v View) At(i int) (byte, error) {
+	if i < 0 || i >= len(v.data) {
+		return 0, fmt.Errorf("view %s: index %d, length %d: %w",
+			v.name(), i, len(v.data), ErrOutOfRange)
+	}
+	return v.data[i], nil
+}
+
+// Count returns the number of residues in the View that are among residues. Case matters.
+func (v View) Count(residues ...byte) int {
+	return countIn(v.data, residueTable(residues))
+}
+
+// GC returns the GC content of the View as defined by Sequence.GC.
+func (v View) GC() float64 {
+	n := v.Count('G', 'C', 'S', 'g', 'c', 's')
+	if n == 0 {
+		return 0
+	}
+	return float64(n) / float64(len(v.data))
+}
+
+// Materialize returns the region of the View as a new Sequence named as in Windows, with its own copy of the data.
+func (v View) Materialize() *Sequence {
+	seq := NewSequence(v.name(), v.data)
+	if v.parent != nil {
+		seq.header = regionHeader(v.parent, v.start, v.end)
+		seq.lineLength = v.parent.lineLength
+		seq.meta = copyMeta(v.parent.meta)
+	}
+	return seq
+}
+
+// name returns the name of the region of the View.
+func (v View) name() string {
+	id := ""
+	if v.parent != nil {
+		id = v.parent.ID()
+	}
+	return fmt.Sprintf("%s:%d-%d", id, v.start, v.end)
+}
+
+// WriteTo writes the View in FASTA format to w, as Materialize followed by Format with KeepLineLength would, but without copying the data. It returns the number of bytes written.
+func (v View) WriteTo(w io.Writer) (int64, error) {
+	seq := &Sequence{header: v.name(), data: v.data}
+	if v.parent != nil {
+		seq.header = regionHeader(v.parent, v.start, v.end)
+		seq.lineLength = v.parent.lineLength
+	}
+	cw := &countingWriter{w: w}
+	err := Format(cw, seq, KeepLineLength)
+	return cw.n, err
+}
+
+// Write writes p to the underlying writer and counts the bytes written.
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// String returns the name of an IrregularityKind.
+func (k IrregularityKind) String() string {
+	switch k {
+	case BlankLine:
+		return "blank line"
+	case TrailingWhitespace:
+		return "trailing whitespace"
+	case InconsistentWidth:
+		return "inconsistent width"
+	case MixedLineEndings:
+		return "mixed line endings"
+	}
+	return fmt.Sprintf("IrregularityKind(%d)", int(k))
+}
+
+// Irregularities returns the irregularities found so far, in the order of their lines.
+func (s *Scanner) Irregularities() []Irregularity {
+	return s.irregular
+}
+
+// checkLine records the irregularities of line, which includes its line ending, if any.
+func (s *Scanner) checkLine(line []byte) {
+	l := bytes.TrimRight(line, "\r\n")
+	t := bytes.TrimRight(l, " \t")
+	isHeader := len(t) > 0 && t[0] == '>'
+	if isHeader {
+		s.lintID = NewSequence(string(t[1:]), nil).ID()
+		s.lintWidth, s.lintShort, s.lintRagged = 0, false, false
+	}
+	ending := ""
+	if bytes.HasSuffix(line, []byte("\r\n")) {
+		ending = "\r\n"
+	} else if bytes.HasSuffix(line, []byte("\n")) {
+		ending = "\n"
+	}
+	if s.firstEnding == "" {
+		s.firstEnding = ending
+	} else if ending != "" && ending != s.firstEnding && !s.mixedEndings {
+		s.mixedEndings = true
+		s.irregularity(MixedLineEndings)
+	}
+	if len(t) == 0 {
+		s.irregularity(BlankLine)
+		return
+	}
+	if len(t) < len(l) {
+		s.irregularity(TrailingWhitespace)
+	}
+	if !isHeader {
+		n := len(t) - bytes.Count(t, []byte(" ")) -
+			bytes.Count(t, []byte("\t"))
+		if s.lintWidth == 0 {
+			s.lintWidth = n
+		} else if (s.lintShort || n > s.lintWidth) && !s.lintRagged {
+			s.lintRagged = true
+			s.irregularity(InconsistentWidth)
+		}
+		s.lintShort = n < s.lintWidth
+	}
+}
+
+// irregularity records an irregularity of kind k on the current line.
+func (s *Scanner) irregularity(k IrregularityKind) {
+	s.irregular = append(s.irregular, Irregularity{k, s.lineNumber,
+		s.lintID})
+}
+
+// String returns the name of a Severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+// MarshalText encodes a Severity by its name, which is how it appears in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// OK reports whether the LintReport is free of errors.
+func (l LintReport) OK() bool {
+	return l.Count(SeverityError) == 0
+}
+
+// Count returns the number of findings with severity s.
+func (l LintReport) Count(s Severity) int {
+	n := 0
+	for _, f := range l.Findings {
+		if f.Severity == s {
+			n++
+		}
+	}
+	return n
+}
+
+// Write writes the LintReport to w for people to read, one finding per line, followed by a summary.
+func (l LintReport) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range l.Findings {
+		if f.Line > 0 {
+			fmt.Fprintf(bw, "line %d: ", f.Line)
+		}
+		if f.ID != "" {
+			fmt.Fprintf(bw, "%s: ", f.ID)
+		}
+		fmt.Fprintf(bw, "%s: %s: %s\n", f.Severity, f.Check, f.Message)
+	}
+	fmt.Fprintf(bw, "%d records, %d errors, %d warnings, %d notes\n",
+		l.Records, l.Count(SeverityError), l.Count(SeverityWarning),
+		l.Count(SeverityInfo))
+	return bw.Flush()
+}
+
+// WriteJSON writes the LintReport to w as a JSON object for programs to read.
+func (l LintReport) WriteJSON(w io.Writer) error {
+	if l.Findings == nil {
+		l.Findings = []LintFinding{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(l)
+}
+
+// Read reads from the underlying reader.
+func (l *lastByteReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	if n > 0 {
+		l.last = p[n-1]
+		l.n += int64(n)
+	}
+	return n, err
+}
+
+// line checks the non-empty line with number n.
+func (l *linter) line(line []byte, n int) {
+	if len(line) > lintLongLine {
+		l.add(SeverityWarning, "long-line", n, l.id,
+			fmt.Sprintf("line of %d bytes", len(line)))
+	}
+	if line[0] == '>' {
+		l.endRecord()
+		l.records++
+		l.inRecord = true
+		l.id = NewSequence(string(line[1:]), nil).ID()
+		l.headerLine = n
+		if first, ok := l.ids[l.id]; ok {
+			l.add(SeverityError, "duplicate-id", n, l.id,
+				fmt.Sprintf("ID first used on line %d", first))
+		} else {
+			l.ids[l.id] = n
+		}
+		l.residues = 0
+		l.upper, l.lower = false, false
+		l.invalid = make(map[byte]int)
+		l.firstInvalid = make(map[byte][2]int)
+		return
+	}
+	if !l.inRecord {
+		if l.headless {
+			return
+		}
+		l.headless = true
+		if line[0] == '@' {
+			l.add(SeverityError, "fastq-like", n, "",
+				"input starts with @, it looks like FASTQ")
+		} else {
+			l.add(SeverityError, "no-header", n, "",
+				"data before the first header")
+		}
+		return
+	}
+	if line[0] == '+' {
+		l.add(SeverityError, "fastq-like", n, l.id,
+			"data line starts with +, it looks like a FASTQ separator")
+	}
+	for _, c := range line {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			l.upper = true
+		case c >= 'a' && c <= 'z':
+			l.lower = true
+		case c == '-' || c == '.' || c == '*':
+		case c == ' ' || c == '\t':
+			continue
+		default:
+			if l.invalid[c] == 0 {
+				l.firstInvalid[c] = [2]int{n, l.residues}
+			}
+			l.invalid[c]++
+		}
+		l.residues++
+	}
+}
+
+// endRecord reports the findings that concern the current record as a whole.
+func (l *linter) endRecord() {
+	if !l.inRecord {
+		return
+	}
+	if l.residues == 0 {
+		l.add(SeverityWarning, "empty-record", l.headerLine, l.id,
+			"record without residues")
+	}
+	if l.upper && l.lower {
+		l.add(SeverityInfo, "mixed-case", l.headerLine, l.id,
+			"record contains upper and lower case residues")
+	}
+	var chars []byte
+	for c := range l.invalid {
+		chars = append(chars, c)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		return l.firstInvalid[chars[i]][1] <
+			l.firstInvalid[chars[j]][1]
+	})
+	for _, c := range chars {
+		f := l.firstInvalid[c]
+		l.add(SeverityError, "non-iupac", f[0], l.id,
+			fmt.Sprintf("character %q occurs %d times, first at "+
+				"position %d", c, l.invalid[c], f[1]+1))
+	}
+	l.inRecord = false
+}
+
+// add adds a finding.
+func (l *linter) add(s Severity, check string, line int, id,
+	msg string) {
+	l.findings = append(l.findings, LintFinding{s, check, line, id,
+		msg})
+}
+
+// Format implements fmt.Formatter. The verb s prints the Sequence in FASTA format as String does; with a precision, say 60, only that many residues are printed, wrapped as usual and followed by an ellipsis if the data was cut. The verb v prints a one-line summary, the ID, the length, and the first ten residues, like seq1 (1,234 bp) ACGTACGTAC…; a precision sets the number of residues. The verb q prints the header as a double-quoted Go string. Other verbs are reported as bad verbs, and a nil Sequence prints as <nil>.
+func (s *Sequence) Format(f fmt.State, verb rune) {
+	if s == nil {
+		io.WriteString(f, "<nil>")
+		return
+	}
+	p, hasPrec := f.Precision()
+	switch verb {
+	case 's':
+		if hasPrec {
+			io.WriteString(f, s.truncated(p))
+		} else {
+			io.WriteString(f, s.String())
+		}
+	case 'v':
+		if !hasPrec {
+			p = summaryResidues
+		}
+		io.WriteString(f, s.summary(p))
+	case 'q':
+		io.WriteString(f, strconv.Quote(s.header))
+	default:
+		fmt.Fprintf(f, "%%!%c(*fasta.Sequence=%s)", verb, s.ID())
+	}
+}
+
+// truncated returns the Sequence in FASTA format with at most n residues, followed by an ellipsis if there are more.
+func (s *Sequence) truncated(n int) string {
+	s.mustLoad()
+	if n >= len(s.data) {
+		return s.String()
+	}
+	t := Sequence{header: s.header, data: s.data[:n],
+		lineLength: s.lineLength}
+	return t.String() + "…"
+}
+
+// summary returns the ID and the length of the Sequence, and its first n residues, followed by an ellipsis if there are more.
+func (s *Sequence) summary(n int) string {
+	l := s.Length()
+	p := fmt.Sprintf("%s (%s bp)", s.ID(), formatThousands(l))
+	if l == 0 || n == 0 {
+		return p
+	}
+	s.mustLoad()
+	if n >= l {
+		return p + " " + string(s.data)
+	}
+	return p + " " + string(s.data[:n]) + "…"
+}
+
+// Error lists the problems separated by semicolons.
+func (e *AlignmentError) Error() string {
+	return "not an alignment: " + strings.Join(e.Problems, "; ")
+}
+
+// Is reports whether target is ErrUnequalLengths and the sequences differ in length.
+func (e *AlignmentError) Is(target error) bool {
+	return target == ErrUnequalLengths && e.unequal
+}
+
+// OK reports whether the two files have the same number of records and all pairs match.
+func (p PairReport) OK() bool {
+	return p.Records1 == p.Records2 && p.Mismatches == 0
+}
+
+// Function NewSequence returns a new Sequence.
+func NewSequence(h string, d []byte) *Sequence {
+	s := new(Sequence)
+	s.header = h
+	s.data = make([]byte, len(d))
+	copy(s.data, d)
+	s.lineLength = DefaultLineLength
+	return s
+}
+
+// initDic constructs the nucleotide dictionary if it doesn't exist yet.
+func initDic() {
+	dicOnce.Do(func() {
+		dic = make([]byte, 256)
+		f := []byte("ACGTUWSMKRYBDHVNacgtuwsmkrybdhvn")
+		r := []byte("TGCAAWSKMYRVHDBNtgcaawskmyrvhdbn")
+		for i, _ := range dic {
+			dic[i] = byte(i)
+		}
+		for i, v := range f {
+			dic[v] = r[i]
+		}
+	})
+}
+
+// stripBlanks removes the spaces and tabs from b in place.
+func stripBlanks(b []byte) []byte {
+	i := bytes.IndexByte(b, ' ')
+	if j := bytes.IndexByte(b, '\t'); j >= 0 && (i < 0 || j < i) {
+		i = j
+	}
+	if i < 0 {
+		return b
+	}
+	d := b[:i]
+	for _, c := range b[i:] {
+		if c != ' ' && c != '\t' {
+			d = append(d, c)
+		}
+	}
+	return d
+}
+
+// ScanSequence reads input Sequence by Sequence.
+func (s *Scanner) ScanSequence() bool {
+	if s.lastSequence {
+		return false
+	}
+	for s.ScanLine() {
+		if s.isHeader {
+			h := string(s.Line()[1:])
+			raw := ""
+			if s.rawHeaders {
+				raw = h
+			}
+			t := strings.TrimSpace(h)
+			if t != h && s.strictHeaders {
+				s.err = fmt.Errorf("line %d: header %q has surrounding "+
+					"whitespace", s.lineNumber, h)
+				s.lastSequence = true
+				return false
+			}
+			h = t
+			s.lastWrap, s.lastInconsistent = s.wrap, s.inconsistent
+			s.wrap, s.inconsistent, s.shortLine = 0, false, false
+			s.previousHeader = s.currentHeader
+			s.currentHeader = h
+			s.previousRaw = s.currentRaw
+			s.currentRaw = raw
+			if s.firstSequence {
+				s.firstSequence = false
+			} else {
+				s.records++
+				return true
+			}
+		} else {
+			if s.data == nil && s.capacity > 0 {
+				s.data = make([]byte, 0, s.capacity)
+			}
+			l := len(s.data)
+			s.data = append(s.data, stripBlanks(s.Line())...)
+			if n := len(s.data) - l; s.preserveWrap && n > 0 {
+				if s.wrap == 0 {
+					s.wrap = n
+				} else if s.shortLine || n > s.wrap {
+					s.inconsistent = true
+				}
+				s.shortLine = n < s.wrap
+			}
+		}
+	}
+	if s.err == io.EOF && len(s.line) > 0 && s.line[0] == '>' {
+		s.line = bytes.TrimRight(s.line, "\r")
+		h := string(s.Line()[1:])
+		raw := ""
+		if s.rawHeaders {
+			raw = h
+		}
+		t := strings.TrimSpace(h)
+		if t != h && s.strictHeaders {
+			s.err = fmt.Errorf("line %d: header %q has surrounding "+
+				"whitespace", s.lineNumber, h)
+			s.lastSequence = true
+			return false
+		}
+		h = t
+		s.lastWrap, s.lastInconsistent = s.wrap, s.inconsistent
+		s.wrap, s.inconsistent, s.shortLine = 0, false, false
+		s.previousHeader = s.currentHeader
+		s.currentHeader = h
+		s.previousRaw = s.currentRaw
+		s.currentRaw = raw
+		if s.firstSequence {
+			s.firstSequence = false
+		} else {
+			s.records++
+			return true
+		}
+		s.line = nil
+	}
+	s.lastSequence = true
+	if s.err == io.EOF {
+		if s.data == nil && s.capacity > 0 {
+			s.data = make([]byte, 0, s.capacity)
+		}
+		l := len(s.data)
+		s.data = append(s.data,
+			stripBlanks(bytes.TrimRight(s.Line(), "\r"))...)
+		if n := len(s.data) - l; s.preserveWrap && n > 0 {
+			if s.wrap == 0 {
+				s.wrap = n
+			} else if s.shortLine || n > s.wrap {
+				s.inconsistent = true
+			}
+			s.shortLine = n < s.wrap
+		}
+	}
+	s.lastWrap, s.lastInconsistent = s.wrap, s.inconsistent
+	s.wrap, s.inconsistent, s.shortLine = 0, false, false
+	s.previousHeader = s.currentHeader
+	s.previousRaw = s.currentRaw
+	if !s.firstSequence {
+		s.records++
+	}
+	if s.progress != nil {
+		s.progress(s.counter.n, s.records)
+	}
+	if !s.firstSequence {
+		return true
+	} else {
+		return false
+	}
+}
+
+// NewScanner returns a new Scanner to read from r.
+func NewScanner(r io.Reader, opts ...ScannerOption) *Scanner {
+	scanner := Scanner{
+		firstSequence: true,
+		bufferSize:    DefaultBufferSize,
+	}
+	for _, opt := range opts {
+		opt(&scanner)
+	}
+	if scanner.progress != nil {
+		scanner.counter = &countingReader{r: r}
+		r = scanner.counter
+	}
+	scanner.r = bufio.NewReaderSize(r, scanner.bufferSize)
+	return &scanner
+}
+
+// ScanN reads at most the first n sequences from r and returns them. To detect the end of the n-th sequence, it reads the header of the next one, and its buffer may read further ahead. If r is an io.Seeker, ScanN repositions it to the start of the first sequence not returned, so the reader can be used again. Otherwise the state of r is undefined after the call.
+func ScanN(r io.Reader, n int) ([]*Sequence, error) {
+	var seqs []*Sequence
+	if n < 1 {
+		return seqs, nil
+	}
+	seeker, isSeeker := r.(io.Seeker)
+	var start int64
+	if isSeeker {
+		var err error
+		start, err = seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			isSeeker = false
+		}
+	}
+	sc := NewScanner(r)
+	for len(seqs) < n && sc.ScanSequence() {
+		seqs = append(seqs, sc.Sequence())
+	}
+	if sc.err != nil && sc.err != io.EOF {
+		return seqs, sc.err
+	}
+	if isSeeker && !sc.lastSequence {
+		_, err := seeker.Seek(start+sc.lineStart, io.SeekStart)
+		if err != nil {
+			return seqs, err
+		}
+	}
+	return seqs, nil
+}
+
+// WithProgress makes the Scanner call fn with the number of bytes read and the number of sequences completed whenever another interval bytes have been read, and once more at the end of the input. The bytes are counted on the reader passed to NewScanner, so when reading compressed data, pass the compressed stream to get counts that map to file size. If interval is less than one, DefaultProgressInterval is used.
+func WithProgress(interval int64,
+	fn func(bytesRead int64, records int)) ScannerOption {
+	return func(s *Scanner) {
+		if interval < 1 {
+			interval = DefaultProgressInterval
+		}
+		s.progress = fn
+		s.progressInterval = interval
+		s.nextProgress = interval
+	}
+}
+
+// NewMultiScanner returns a new MultiScanner to read from readers in turn.
+func NewMultiScanner(readers ...io.Reader) *MultiScanner {
+	m := new(MultiScanner)
+	m.readers = readers
+	return m
+}
+
+// NewPairScanner returns a new PairScanner to read from r1 and r2.
+func NewPairScanner(r1, r2 io.Reader) *PairScanner {
+	p := new(PairScanner)
+	p.sc1 = NewScanner(r1)
+	p.sc2 = NewScanner(r2)
+	return p
+}
+
+// checkPair returns an error naming pair n if the names of its mates s1 and s2 don't match, or if a mate suffix is present but s1 isn't mate /1 and s2 mate /2.
+func checkPair(s1, s2 *Sequence, n int) error {
+	n1, m1 := pairName(s1.header)
+	n2, m2 := pairName(s2.header)
+	if n1 != n2 {
+		return fmt.Errorf("pair %d: names %q and %q don't match",
+			n, s1.header, s2.header)
+	}
+	if (m1 != 0 || m2 != 0) && (m1 != '1' || m2 != '2') {
+		return fmt.Errorf("pair %d: want mates /1 and /2, "+
+			"get %q and %q", n, s1.header, s2.header)
+	}
+	return nil
+}
+
+// pairName returns the name of a mate with header h and its mate number, '1' or '2', or 0 if the name has no mate suffix.
+func pairName(h string) (string, byte) {
+	fields := strings.Fields(h)
+	if len(fields) == 0 {
+		return "", 0
+	}
+	name := fields[0]
+	if strings.HasSuffix(name, "/1") ||
+		strings.HasSuffix(name, "/2") {
+		return name[:len(name)-2], name[len(name)-1]
+	}
+	return name, 0
+}
+
+// Interleave reads pairs of sequences from r1 and r2 and writes them alternately to w.
+func Interleave(r1, r2 io.Reader, w io.Writer) error {
+	p := NewPairScanner(r1, r2)
+	for p.ScanPair() {
+		s1, s2 := p.Pair()
+		_, err := fmt.Fprintf(w, "%s\n%s\n", s1, s2)
+		if err != nil {
+			return err
+		}
+	}
+	return p.Err()
+}
+
+// Deinterleave reads alternating mates from r and writes the first mates to w1, the second mates to w2.
+func Deinterleave(r io.Reader, w1, w2 io.Writer) error {
+	sc := NewScanner(r)
+	n := 0
+	for sc.ScanSequence() {
+		s1 := sc.Sequence()
+		n++
+		if !sc.ScanSequence() {
+			if err := sc.Err(); err != nil {
+				return err
+			}
+			return fmt.Errorf("pair %d: %q has no mate", n, s1.header)
+		}
+		s2 := sc.Sequence()
+		if err := checkPair(s1, s2, n); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w1, "%s\n", s1); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w2, "%s\n", s2); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// WithBufferSize sets the size of the read buffer of the Scanner; the default is DefaultBufferSize.
+func WithBufferSize(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.bufferSize = n
+	}
+}
+
+// WithCapacityHint sets the capacity, n, of the buffer the Scanner accumulates residues in.
+func WithCapacityHint(n int) ScannerOption {
+	return func(s *Scanner) {
+		s.capacity = n
+	}
+}
+
+// WithHandoff lets Sequence pass its data buffer to the returned Sequence instead of copying it, as long as the buffer isn't much larger than the data.
+func WithHandoff() ScannerOption {
+	return func(s *Scanner) {
+		s.handoff = true
+	}
+}
+
+// ReadFile reads all sequences in the file name, or from the standard input if name is “-”. Gzipped input is decompressed. It uses the size of the file as capacity hint and hands off data buffers.
+func ReadFile(name string) ([]*Sequence, error) {
+	if name == "-" {
+		return ReadStdin()
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	return readAll(f, int(fi.Size()))
+}
+
+// WithStrictHeaders makes the Scanner stop with an error when it encounters a header with leading or trailing whitespace instead of trimming it.
+func WithStrictHeaders() ScannerOption {
+	return func(s *Scanner) {
+		s.strictHeaders = true
+	}
+}
+
+// NewFastqScanner returns a new FastqScanner to read from r.
+func NewFastqScanner(r io.Reader) *FastqScanner {
+	f := new(FastqScanner)
+	f.r = bufio.NewReader(r)
+	return f
+}
+
+// FastqToFasta reads FASTQ records from r and writes them to w in FASTA format.
+func FastqToFasta(r io.Reader, w io.Writer) error {
+	sc := NewFastqScanner(r)
+	for sc.ScanRecord() {
+		s := sc.Record().ToSequence()
+		if _, err := fmt.Fprintf(w, "%s\n", s); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// NewQualSequence returns a new QualSequence with quality offset DefaultQualityOffset. It returns an error if the numbers of residues and quality values differ.
+func NewQualSequence(h string, d, q []byte) (*QualSequence, error) {
+	if len(d) != len(q) {
+		return nil, fmt.Errorf("%q: %d residues but %d "+
+			"quality values: %w", h, len(d), len(q),
+			ErrUnequalLengths)
+	}
+	qs := new(QualSequence)
+	qs.header = h
+	qs.data = make([]byte, len(d))
+	copy(qs.data, d)
+	qs.quality = make([]byte, len(q))
+	copy(qs.quality, q)
+	qs.lineLength = DefaultLineLength
+	qs.offset = DefaultQualityOffset
+	return qs, nil
+}
+
+// ReadQual reads a QUAL file and returns the Phred scores keyed by header. Duplicate headers and scores that aren't integers are errors.
+func ReadQual(r io.Reader) (map[string][]int, error) {
+	quals := make(map[string][]int)
+	sc := NewScanner(r)
+	header := ""
+	seen := false
+	for sc.ScanLine() {
+		line := sc.Line()
+		if len(line) > 0 && line[0] == '>' {
+			header = strings.TrimSpace(string(line[1:]))
+			if _, ok := quals[header]; ok {
+				return nil, fmt.Errorf("QUAL record: %w %q", ErrDuplicateID,
+					header)
+			}
+			quals[header] = []int{}
+			seen = true
+		} else if len(line) > 0 {
+			if !seen {
+				return nil, fmt.Errorf("scores: %w", ErrNoHeader)
+			}
+			for _, f := range strings.Fields(string(line)) {
+				q, err := strconv.Atoi(f)
+				if err != nil {
+					return nil, fmt.Errorf("QUAL record %q: %w", header, err)
+				}
+				quals[header] = append(quals[header], q)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	line := bytes.TrimSpace(sc.Flush())
+	if len(line) > 0 && line[0] == '>' {
+		header = strings.TrimSpace(string(line[1:]))
+		if _, ok := quals[header]; ok {
+			return nil, fmt.Errorf("QUAL record: %w %q", ErrDuplicateID,
+				header)
+		}
+		quals[header] = []int{}
+		seen = true
+	} else if len(line) > 0 {
+		if !seen {
+			return nil, fmt.Errorf("scores: %w", ErrNoHeader)
+		}
+		for _, f := range strings.Fields(string(line)) {
+			q, err := strconv.Atoi(f)
+			if err != nil {
+				return nil, fmt.Errorf("QUAL record %q: %w", header, err)
+			}
+			quals[header] = append(quals[header], q)
+		}
+	}
+	return quals, nil
+}
+
+// AttachQual combines sequences with the Phred scores read by ReadQual into QualSequences with the default quality offset. It returns an error naming the record if a sequence has no scores, a set of scores has no sequence, the numbers of residues and scores differ, or a score can't be encoded.
+func AttachQual(seqs []*Sequence,
+	quals map[string][]int) ([]*QualSequence, error) {
+	var recs []*QualSequence
+	used := make(map[string]bool)
+	for _, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return nil, err
+		}
+		q, ok := quals[s.header]
+		if !ok {
+			return nil, fmt.Errorf("no scores for %q", s.header)
+		}
+		if len(q) != s.Length() {
+			return nil, fmt.Errorf("%q: %d residues but %d scores: %w",
+				s.header, s.Length(), len(q), ErrUnequalLengths)
+		}
+		enc := make([]byte, len(q))
+		for i, v := range q {
+			c := v + DefaultQualityOffset
+			if v < 0 || c > 126 {
+				return nil, fmt.Errorf("%q: can't encode score %d",
+					s.header, v)
+			}
+			enc[i] = byte(c)
+		}
+		qs, _ := NewQualSequence(s.header, s.Data(), enc)
+		qs.lineLength = s.lineLength
+		recs = append(recs, qs)
+		used[s.header] = true
+	}
+	var unused []string
+	for h := range quals {
+		if !used[h] {
+			unused = append(unused, h)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, fmt.Errorf("no sequence for scores %q", unused[0])
+	}
+	return recs, nil
+}
+
+// WriteQual writes the Phred scores of recs in QUAL format to w with wrap scores per line. If wrap is NoWrap, all scores of a record are written on one line. A negative wrap is an error wrapping ErrLineLength.
+func WriteQual(w io.Writer, recs []*QualSequence, wrap int) error {
+	if err := checkLineLength(wrap); err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, r := range recs {
+		fmt.Fprintf(bw, ">%s\n", r.header)
+		for i, c := range r.quality {
+			if i > 0 {
+				if wrap > 0 && i%wrap == 0 {
+					bw.WriteByte('\n')
+				} else {
+					bw.WriteByte(' ')
+				}
+			}
+			bw.WriteString(strconv.Itoa(int(c) - r.offset))
+		}
+		if len(r.quality) > 0 {
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteFastq writes recs to w in the canonical four-line FASTQ format without wrapping and with a bare '+' as separator.
+func WriteFastq(w io.Writer, recs []*QualSequence) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range recs {
+		if len(r.data) != len(r.quality) {
+			return fmt.Errorf("%q: %d residues but %d "+
+				"quality values: %w", r.header, len(r.data),
+				len(r.quality), ErrUnequalLengths)
+		}
+		fmt.Fprintf(bw, "@%s\n%s\n+\n%s\n", r.header, r.data,
+			r.quality)
+	}
+	return bw.Flush()
+}
+
+// SequenceToFastq returns a copy of s ready for writing as FASTQ, where every residue has the quality character qual.
+func SequenceToFastq(s *Sequence, qual byte) *QualSequence {
+	return s.ToQualSequence(qual)
+}
+
+// ReadGenBank reads the records in a GenBank file and returns their sequences. The header of a sequence consists of the LOCUS name followed by the DEFINITION. Records without ORIGIN, like those with a CONTIG line instead, are skipped. If any were skipped, the sequences read are returned together with an error giving the number of records skipped.
+func ReadGenBank(r io.Reader) ([]*Sequence, error) {
+	var seqs []*Sequence
+	lr := &lineReader{r: bufio.NewReader(r)}
+	var name, def string
+	var data []byte
+	inDef, inOrigin, hasOrigin := false, false, false
+	skipped := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		if len(line) > 0 && (line[0] == ' ' || line[0] == '\t') {
+			if inDef {
+				def += " " + strings.TrimSpace(string(line))
+			} else if inOrigin {
+				for _, c := range line {
+					if c != ' ' && c != '\t' && (c < '0' || c > '9') {
+						data = append(data, c)
+					}
+				}
+			}
+		} else {
+			inDef, inOrigin = false, false
+			fields := strings.Fields(string(line))
+			if len(fields) > 0 {
+				switch fields[0] {
+				case "LOCUS":
+					name, def, data, hasOrigin = "", "", nil, false
+					if len(fields) > 1 {
+						name = fields[1]
+					} else {
+						name = "?"
+					}
+				case "DEFINITION":
+					def = strings.Join(fields[1:], " ")
+					inDef = true
+				case "ORIGIN":
+					inOrigin, hasOrigin = true, true
+				case "//":
+					if hasOrigin {
+						h := composeHeader(name, def)
+						seqs = append(seqs, NewSequence(h, data))
+					} else {
+						skipped++
+					}
+					name = ""
+				}
+			}
+		}
+	}
+	if lr.err != nil {
+		return seqs, lr.err
+	}
+	if name != "" {
+		return seqs, fmt.Errorf("GenBank record %q not terminated "+
+			"by //", name)
+	}
+	if skipped > 0 {
+		return seqs, fmt.Errorf("skipped %d GenBank records "+
+			"without ORIGIN", skipped)
+	}
+	return seqs, nil
+}
+
+// ReadEMBL reads the records in an EMBL file and returns their sequences. The header of a sequence consists of the accession on the ID line followed by the description.
+func ReadEMBL(r io.Reader) ([]*Sequence, error) {
+	var seqs []*Sequence
+	lr := &lineReader{r: bufio.NewReader(r)}
+	var id, desc string
+	var data []byte
+	inSeq := false
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		code := ""
+		if len(line) >= 2 {
+			code = string(line[:2])
+		}
+		if inSeq && code != "//" {
+			for _, c := range line {
+				if c != ' ' && c != '\t' && (c < '0' || c > '9') {
+					data = append(data, c)
+				}
+			}
+			continue
+		}
+		switch code {
+		case "ID":
+			fields := strings.Fields(string(line[2:]))
+			id = "?"
+			if len(fields) > 0 {
+				id = strings.TrimSuffix(fields[0], ";")
+			}
+			desc, data, inSeq = "", nil, false
+		case "DE":
+			d := strings.TrimSpace(string(line[2:]))
+			if desc == "" {
+				desc = d
+			} else {
+				desc += " " + d
+			}
+		case "SQ":
+			inSeq = true
+		case "//":
+			h := composeHeader(id, desc)
+			seqs = append(seqs, NewSequence(h, data))
+			id, inSeq = "", false
+		}
+	}
+	if lr.err != nil {
+		return seqs, lr.err
+	}
+	if id != "" {
+		return seqs, fmt.Errorf("EMBL record %q not terminated by //",
+			id)
+	}
+	return seqs, nil
+}
+
+// WriteTab writes seqs to w as a table with one row per sequence consisting of header and data separated by a tab. Each function in extraCols adds a column computed from the sequence. Headers or extra columns containing tabs or newlines are rejected with an error.
+func WriteTab(w io.Writer, seqs []*Sequence,
+	extraCols ...func(*Sequence) string) error {
+	bw := bufio.NewWriter(w)
+	for _, s := range seqs {
+		if strings.ContainsAny(s.header, "\t\n") {
+			return fmt.Errorf("header %q contains tab or newline",
+				s.header)
+		}
+		if err := s.Materialize(); err != nil {
+			return err
+		}
+		fmt.Fprintf(bw, "%s\t%s", s.header, s.Data())
+		for _, col := range extraCols {
+			c := col(s)
+			if strings.ContainsAny(c, "\t\n") {
+				return fmt.Errorf("%q: column %q contains tab or "+
+					"newline", s.header, c)
+			}
+			fmt.Fprintf(bw, "\t%s", c)
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReadTab reads sequences from a table written by WriteTab. Columns beyond the second are ignored and empty lines skipped. Lines with fewer than two columns are skipped, too, and reported by their numbers in an error returned together with the sequences read.
+func ReadTab(r io.Reader) ([]*Sequence, error) {
+	var seqs []*Sequence
+	var bad []string
+	lr := &lineReader{r: bufio.NewReader(r)}
+	n := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		n++
+		if len(line) == 0 {
+			continue
+		}
+		fields := bytes.SplitN(line, []byte("\t"), 3)
+		if len(fields) < 2 {
+			bad = append(bad, strconv.Itoa(n))
+			continue
+		}
+		seqs = append(seqs, NewSequence(string(fields[0]), fields[1]))
+	}
+	if lr.err != nil {
+		return seqs, lr.err
+	}
+	if len(bad) > 0 {
+		return seqs, fmt.Errorf("skipped lines with fewer than two "+
+			"columns: %s", strings.Join(bad, ", "))
+	}
+	return seqs, nil
+}
+
+// Filter returns the sequences in seqs for which keep returns true, in their original order.
+func Filter(seqs []*Sequence, keep func(*Sequence) bool) []*Sequence {
+	var kept []*Sequence
+	for _, s := range seqs {
+		if keep(s) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
+
+// MinLength returns a predicate that is true for sequences at least n residues long.
+func MinLength(n int) func(*Sequence) bool {
+	return func(s *Sequence) bool {
+		return s.Length() >= n
+	}
+}
+
+// MaxLength returns a predicate that is true for sequences at most n residues long.
+func MaxLength(n int) func(*Sequence) bool {
+	return func(s *Sequence) bool {
+		return s.Length() <= n
+	}
+}
+
+// GCBetween returns a predicate that is true for sequences with a GC content between lo and hi. Empty sequences have no GC content and are never kept.
+func GCBetween(lo, hi float64) func(*Sequence) bool {
+	return func(s *Sequence) bool {
+		if s.Length() == 0 {
+			return false
+		}
+		gc := s.GC()
+		return gc >= lo && gc <= hi
+	}
+}
+
+// HeaderContains returns a predicate that is true for sequences whose header contains substr.
+func HeaderContains(substr string) func(*Sequence) bool {
+	return func(s *Sequence) bool {
+		return strings.Contains(s.header, substr)
+	}
+}
+
+// FilterStream reads sequences from r and writes those for which keep returns true to w. It holds only one sequence in memory at a time and returns the numbers of sequences kept and dropped.
+func FilterStream(r io.Reader, w io.Writer,
+	keep func(*Sequence) bool) (kept, dropped int, err error) {
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		if !keep(s) {
+			dropped++
+			continue
+		}
+		if _, err = fmt.Fprintf(w, "%s\n", s); err != nil {
+			return kept, dropped, err
+		}
+		kept++
+	}
+	return kept, dropped, sc.Err()
+}
+
+// SortBy sorts seqs stably according to less.
+func SortBy(seqs []*Sequence, less func(a, b *Sequence) bool) {
+	sort.SliceStable(seqs, func(i, j int) bool {
+		return less(seqs[i], seqs[j])
+	})
+}
+
+// SortByLength sorts seqs stably by length, in ascending order unless descending is true.
+func SortByLength(seqs []*Sequence, descending bool) {
+	SortBy(seqs, func(a, b *Sequence) bool {
+		if descending {
+			return a.Length() > b.Length()
+		}
+		return a.Length() < b.Length()
+	})
+}
+
+// SortByHeader sorts seqs stably by header in natural order, where runs of digits are compared as numbers, so chr2 comes before chr10.
+func SortByHeader(seqs []*Sequence) {
+	SortBy(seqs, func(a, b *Sequence) bool {
+		return CompareNatural(a.header, b.header) < 0
+	})
+}
+
+// CompareNatural returns a negative number if a comes before b in natural order, a positive number if it comes after, and zero if the two are equal. In natural order, runs of digits are compared as numbers, and strings that only differ in leading zeros, like chr01 and chr1, are ordered lexically.
+func CompareNatural(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			k, l := i, j
+			for k < len(a) && isDigit(a[k]) {
+				k++
+			}
+			for l < len(b) && isDigit(b[l]) {
+				l++
+			}
+			x := strings.TrimLeft(a[i:k], "0")
+			y := strings.TrimLeft(b[j:l], "0")
+			if len(x) != len(y) {
+				return len(x) - len(y)
+			}
+			if c := strings.Compare(x, y); c != 0 {
+				return c
+			}
+			i, j = k, l
+		} else {
+			if a[i] != b[j] {
+				return int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+	}
+	if i < len(a) || j < len(b) {
+		return (len(a) - i) - (len(b) - j)
+	}
+	return strings.Compare(a, b)
+}
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// SortByKeys sorts seqs stably by keys, where ties under a key are broken by the next key. Sequences that tie under all keys keep their relative order.
+func SortByKeys(seqs []*Sequence, keys ...SortKey) {
+	SortBy(seqs, func(a, b *Sequence) bool {
+		for _, k := range keys {
+			if c := k(a, b); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+// ByLengthAsc orders sequences from short to long.
+func ByLengthAsc(a, b *Sequence) int {
+	return a.Length() - b.Length()
+}
+
+// ByLengthDesc orders sequences from long to short.
+func ByLengthDesc(a, b *Sequence) int {
+	return b.Length() - a.Length()
+}
+
+// ByIDNatural orders sequences by ID in natural order.
+func ByIDNatural(a, b *Sequence) int {
+	return CompareNatural(a.ID(), b.ID())
+}
+
+// ByGC orders sequences by increasing GC content. The GC content is computed anew for every comparison, so for long sequences it may pay to sort by a custom key on precomputed values instead.
+func ByGC(a, b *Sequence) int {
+	x, y := a.GC(), b.GC()
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// composeHeader returns the header with ID id and description desc.
+func composeHeader(id, desc string) string {
+	return strings.TrimSpace(id + " " + desc)
+}
+
+// regionHeader returns the header of the region from start to end of s, id:start-end followed by the description of s.
+func regionHeader(s *Sequence, start, end int) string {
+	id := fmt.Sprintf("%s:%d-%d", s.ID(), start, end)
+	return composeHeader(id, s.Description())
+}
+
+// DeduplicateByData returns the sequences in seqs with distinct data, keeping the first occurrence of each. It also returns a map from the header of each sequence kept to the headers of the duplicates merged into it.
+func DeduplicateByData(seqs []*Sequence) (unique []*Sequence,
+	dupes map[string][]string) {
+	return dedupData(seqs, false)
+}
+
+// DeduplicateByDataFold is like DeduplicateByData, except that data differing only in case counts as identical.
+func DeduplicateByDataFold(seqs []*Sequence) (unique []*Sequence,
+	dupes map[string][]string) {
+	return dedupData(seqs, true)
+}
+func dedupData(seqs []*Sequence, fold bool) ([]*Sequence,
+	map[string][]string) {
+	var unique []*Sequence
+	dupes := make(map[string][]string)
+	buckets := make(map[uint64][]*Sequence)
+	for _, s := range seqs {
+		h := hashData(s.Data(), fold)
+		found := false
+		for _, u := range buckets[h] {
+			if equalData(u.Data(), s.Data(), fold) {
+				dupes[u.header] = append(dupes[u.header], s.header)
+				found = true
+				break
+			}
+		}
+		if !found {
+			buckets[h] = append(buckets[h], s)
+			unique = append(unique, s)
+		}
+	}
+	return unique, dupes
+}
+func hashData(d []byte, fold bool) uint64 {
+	h := uint64(14695981039346656037)
+	for _, c := range d {
+		if fold && c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+func equalData(a, b []byte, fold bool) bool {
+	if fold {
+		return len(a) == len(b) && bytes.EqualFold(a, b)
+	}
+	return bytes.Equal(a, b)
+}
+
+// DeduplicateByID returns the sequences in seqs with distinct identifiers, keeping the first occurrence of each. It also returns a map from the header of each sequence kept to the headers of the duplicates dropped.
+func DeduplicateByID(seqs []*Sequence) (unique []*Sequence,
+	dupes map[string][]string) {
+	dupes = make(map[string][]string)
+	first := make(map[string]*Sequence)
+	for _, s := range seqs {
+		id := s.ID()
+		if f, ok := first[id]; ok {
+			dupes[f.header] = append(dupes[f.header], s.header)
+		} else {
+			first[id] = s
+			unique = append(unique, s)
+		}
+	}
+	return unique, dupes
+}
+
+// DeduplicateCanonical is like DeduplicateByData, except that a sequence and its reverse complement count as identical. The headers of duplicates that matched as reverse complements carry the suffix ReverseComplementMark in the map returned.
+func DeduplicateCanonical(seqs []*Sequence) ([]*Sequence,
+	map[string][]string) {
+	var unique []*Sequence
+	dupes := make(map[string][]string)
+	buckets := make(map[uint64][]*Sequence)
+	initDic()
+	for _, s := range seqs {
+		h := hashCanonical(s.Data())
+		found := false
+		for _, u := range buckets[h] {
+			if bytes.Equal(u.Data(), s.Data()) {
+				dupes[u.header] = append(dupes[u.header], s.header)
+				found = true
+			} else if isRevComp(u.Data(), s.Data()) {
+				dupes[u.header] = append(dupes[u.header],
+					s.header+ReverseComplementMark)
+				found = true
+			}
+			if found {
+				break
+			}
+		}
+		if !found {
+			buckets[h] = append(buckets[h], s)
+			unique = append(unique, s)
+		}
+	}
+	return unique, dupes
+}
+func isRevComp(a, b []byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	n := len(a)
+	for i, c := range a {
+		if dic[b[n-1-i]] != c {
+			return false
+		}
+	}
+	return true
+}
+func hashCanonical(d []byte) uint64 {
+	n := len(d)
+	reverse := false
+	for i, c := range d {
+		r := dic[d[n-1-i]]
+		if c != r {
+			reverse = r < c
+			break
+		}
+	}
+	if !reverse {
+		return hashData(d, false)
+	}
+	h := uint64(14695981039346656037)
+	for i := n - 1; i >= 0; i-- {
+		h ^= uint64(dic[d[i]])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// Stats returns the summary statistics of seqs.
+func Stats(seqs []*Sequence) AssemblyStats {
+	c := new(statsCounter)
+	for _, s := range seqs {
+		c.add(s)
+	}
+	return c.stats()
+}
+
+// StatsStream returns the summary statistics of the sequences read from r, holding only one sequence in memory at a time.
+func StatsStream(r io.Reader) (AssemblyStats, error) {
+	c := new(statsCounter)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		c.add(sc.Sequence())
+	}
+	return c.stats(), sc.Err()
+}
+
+// WriteStatsRow writes the statistics of s as one row of a statistics table to w.
+func WriteStatsRow(w io.Writer, s *Sequence) error {
+	if err := s.Materialize(); err != nil {
+		return err
+	}
+	t := s.Counts()
+	gc := 0.0
+	g := t['G'] + t['C'] + t['g'] + t['c']
+	if acgt := g + t['A'] + t['T'] + t['a'] + t['t']; acgt > 0 {
+		gc = float64(g) / float64(acgt)
+	}
+	lower := 0.0
+	if s.Length() > 0 {
+		lower = float64(countLower(&t)) / float64(s.Length())
+	}
+	sum := md5.Sum(bytes.ToUpper(s.Data()))
+	_, err := fmt.Fprintf(w, "%s\t%d\t%.4f\t%d\t%.4f\t%x\n", s.ID(),
+		s.Length(), gc, t['N']+t['n'], lower, sum)
+	return err
+}
+
+// WriteStatsTable writes a statistics table of seqs to w, starting with StatsTableHeader. To write a table without header, call WriteStatsRow for each sequence.
+func WriteStatsTable(w io.Writer, seqs []*Sequence) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, StatsTableHeader)
+	for _, s := range seqs {
+		if err := WriteStatsRow(bw, s); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// WriteStatsTableStream writes a statistics table of the sequences read from r to w, holding only one sequence in memory at a time.
+func WriteStatsTableStream(w io.Writer, r io.Reader) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, StatsTableHeader)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		if err := WriteStatsRow(bw, sc.Sequence()); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Concatenate returns a new Sequence consisting of the sequences in seqs joined by sep. Its header consists of the original headers joined by sep. The input sequences are not changed. An empty slice is an error.
+func Concatenate(seqs []*Sequence, sep string) (*Sequence, error) {
+	if len(seqs) == 0 {
+		return nil, errors.New("no sequences to concatenate")
+	}
+	n := len(sep) * (len(seqs) - 1)
+	headers := make([]string, len(seqs))
+	for i, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return nil, err
+		}
+		n += s.Length()
+		headers[i] = s.header
+	}
+	data := make([]byte, 0, n)
+	for i, s := range seqs {
+		if i > 0 {
+			data = append(data, sep...)
+		}
+		data = append(data, s.Data()...)
+	}
+	c := &Sequence{header: strings.Join(headers, sep), data: data,
+		lineLength: DefaultLineLength}
+	return c, nil
+}
+
+// Deconcatenate is the inverse of Concatenate. It splits the data and the header of s at sep and returns the resulting sequences. Empty segments, as between consecutive separators, give empty sequences or empty headers, so the concatenation of sequences without data is also inverted. It is an error if sep is empty or if the numbers of data and header segments differ.
+func Deconcatenate(s *Sequence, sep string) ([]*Sequence, error) {
+	if sep == "" {
+		return nil, errors.New("empty separator")
+	}
+	if err := s.Materialize(); err != nil {
+		return nil, err
+	}
+	data := bytes.Split(s.Data(), []byte(sep))
+	headers := strings.Split(s.header, sep)
+	if len(data) != len(headers) {
+		return nil, fmt.Errorf("%d data segments but %d headers",
+			len(data), len(headers))
+	}
+	seqs := make([]*Sequence, len(data))
+	for i, d := range data {
+		seqs[i] = NewSequence(headers[i], d)
+		seqs[i].lineLength = s.lineLength
+	}
+	return seqs, nil
+}
+
+// ConcatenateMapped is like Concatenate, but also returns a CoordMap for translating positions in the concatenated sequence back to the original sequences.
+func ConcatenateMapped(seqs []*Sequence,
+	sep string) (*Sequence, *CoordMap, error) {
+	c, err := Concatenate(seqs, sep)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := new(CoordMap)
+	start := 0
+	for _, s := range seqs {
+		m.names = append(m.names, s.header)
+		m.starts = append(m.starts, start)
+		m.ends = append(m.ends, start+s.Length())
+		start += s.Length() + len(sep)
+	}
+	return c, m, nil
+}
+
+// ReadCoordMap reads a CoordMap written by Write.
+func ReadCoordMap(r io.Reader) (*CoordMap, error) {
+	m := new(CoordMap)
+	lr := &lineReader{r: bufio.NewReader(r)}
+	n := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		n++
+		fields := strings.Split(string(line), "\t")
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("line %d: want 3 columns, get %d", n,
+				len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || start > end {
+			return nil, fmt.Errorf("line %d: invalid interval", n)
+		}
+		if k := len(m.ends); k > 0 && start < m.ends[k-1] {
+			return nil, fmt.Errorf("line %d: interval out of order", n)
+		}
+		m.names = append(m.names, fields[0])
+		m.starts = append(m.starts, start)
+		m.ends = append(m.ends, end)
+	}
+	return m, lr.err
+}
+
+// Sample returns a uniform random sample of n sequences from seqs in their original order. If n is at least the number of sequences, all of them are returned. If r is nil, a package-default source of randomness is used.
+func Sample(seqs []*Sequence, n int, r *rand.Rand) []*Sequence {
+	r = randOrDefault(r)
+	res := newReservoir(n)
+	for _, s := range seqs {
+		res.add(s, r)
+	}
+	return res.sequences()
+}
+
+// SampleStream returns a uniform random sample of n sequences read from rd in their original order. It holds at most n+1 sequences in memory. If n is at least the number of sequences, all of them are returned. If r is nil, a package-default source of randomness is used.
+func SampleStream(rd io.Reader, n int,
+	r *rand.Rand) ([]*Sequence, error) {
+	r = randOrDefault(r)
+	res := newReservoir(n)
+	sc := NewScanner(rd)
+	for sc.ScanSequence() {
+		res.add(sc.Sequence(), r)
+	}
+	return res.sequences(), sc.Err()
+}
+func newReservoir(n int) *reservoir {
+	if n < 0 {
+		n = 0
+	}
+	return &reservoir{n: n}
+}
+
+// SampleFraction reads sequences from rd and writes each of them to w with probability p. It returns the number of sequences written. If r is nil, a package-default source of randomness is used.
+func SampleFraction(rd io.Reader, w io.Writer, p float64,
+	r *rand.Rand) (int, error) {
+	r = randOrDefault(r)
+	kept := 0
+	sc := NewScanner(rd)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		if r.Float64() >= p {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", s); err != nil {
+			return kept, err
+		}
+		kept++
+	}
+	return kept, sc.Err()
+}
+
+// Diff compares the records in a and b by identifier. Duplicate identifiers in either input are an error.
+func Diff(a, b io.Reader) (DiffReport, error) {
+	var rep DiffReport
+	sums := make(map[string]digests)
+	var order []string
+	sc := NewScanner(a)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		id := s.ID()
+		if _, ok := sums[id]; ok {
+			return rep, fmt.Errorf("%w %q in first input",
+				ErrDuplicateID, id)
+		}
+		sums[id] = newDigests(s.Data())
+		order = append(order, id)
+	}
+	if err := sc.Err(); err != nil {
+		return rep, err
+	}
+	seen := make(map[string]bool)
+	sc = NewScanner(b)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		id := s.ID()
+		if seen[id] {
+			return rep, fmt.Errorf("%w %q in second input",
+				ErrDuplicateID, id)
+		}
+		seen[id] = true
+		da, ok := sums[id]
+		if !ok {
+			rep.OnlyB = append(rep.OnlyB, id)
+			continue
+		}
+		db := newDigests(s.Data())
+		if da.exact == db.exact {
+			rep.Identical = append(rep.Identical, id)
+		} else {
+			rep.Different = append(rep.Different, RecordDiff{
+				ID: id, LengthA: da.length, LengthB: db.length,
+				CaseOnly:   da.upper == db.upper,
+				StrandOnly: da.upper == db.rcUpper,
+			})
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return rep, err
+	}
+	for _, id := range order {
+		if !seen[id] {
+			rep.OnlyA = append(rep.OnlyA, id)
+		}
+	}
+	return rep, nil
+}
+func newDigests(d []byte) digests {
+	var ds digests
+	ds.length = len(d)
+	ds.exact = md5.Sum(d)
+	initDic()
+	buf := make([]byte, 0, 4096)
+	up, rc := md5.New(), md5.New()
+	for _, c := range d {
+		buf = append(buf, upper(c))
+		if len(buf) == cap(buf) {
+			up.Write(buf)
+			buf = buf[:0]
+		}
+	}
+	up.Write(buf)
+	buf = buf[:0]
+	for i := len(d) - 1; i >= 0; i-- {
+		buf = append(buf, upper(dic[d[i]]))
+		if len(buf) == cap(buf) {
+			rc.Write(buf)
+			buf = buf[:0]
+		}
+	}
+	rc.Write(buf)
+	copy(ds.upper[:], up.Sum(nil))
+	copy(ds.rcUpper[:], rc.Sum(nil))
+	return ds
+}
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - ('a' - 'A')
+	}
+	return c
+}
+
+// Merge reads the records from inputs in turn and writes them to w, resolving identifier collisions according to policy. Except for KeepLast, records are streamed. With KeepLast, all records are held in memory until the last input has been read, as any of them might still be replaced.
+func Merge(w io.Writer, policy MergePolicy,
+	inputs ...io.Reader) (MergeReport, error) {
+	rep := MergeReport{Written: make([]int, len(inputs)),
+		Dropped: make([]int, len(inputs))}
+	seen := make(map[string]mergeEntry)
+	var kept []*Sequence
+	var from []int
+	bw := bufio.NewWriter(w)
+	ms := NewMultiScanner(inputs...)
+	for ms.ScanSequence() {
+		s := ms.Sequence()
+		i := ms.Index()
+		id := s.ID()
+		sum := md5.Sum(s.Data())
+		if e, ok := seen[id]; ok {
+			rep.Collisions++
+			switch policy {
+			case MergeError:
+				return rep, fmt.Errorf("input %d: %w %q", i,
+					ErrDuplicateID, id)
+			case KeepFirst:
+				rep.Dropped[i]++
+				continue
+			case KeepLast:
+				rep.Dropped[from[e.pos]]++
+				kept[e.pos] = s
+				from[e.pos] = i
+				seen[id] = mergeEntry{sum: sum, pos: e.pos}
+				continue
+			case RenameWithSuffix:
+				n := 2
+				for {
+					if _, ok := seen[id+"_"+strconv.Itoa(n)]; !ok {
+						break
+					}
+					n++
+				}
+				id = id + "_" + strconv.Itoa(n)
+				s.header = composeHeader(id, s.Description())
+				s.rawHeader = ""
+				rep.Renamed = append(rep.Renamed, id)
+			case KeepIfIdentical:
+				if e.sum != sum {
+					return rep, fmt.Errorf("input %d: %w %q with "+
+						"different data", i, ErrDuplicateID, id)
+				}
+				rep.Dropped[i]++
+				continue
+			}
+		}
+		seen[id] = mergeEntry{sum: sum, pos: len(from)}
+		from = append(from, i)
+		if policy == KeepLast {
+			kept = append(kept, s)
+		} else {
+			if _, err := fmt.Fprintf(bw, "%s\n", s); err != nil {
+				return rep, err
+			}
+			rep.Written[i]++
+		}
+	}
+	if err := ms.Err(); err != nil {
+		return rep, err
+	}
+	for j, s := range kept {
+		if _, err := fmt.Fprintf(bw, "%s\n", s); err != nil {
+			return rep, err
+		}
+		rep.Written[from[j]]++
+	}
+	return rep, bw.Flush()
+}
+
+// Reorder returns seqs arranged in the order of their identifiers in names. In strict mode, a name without sequence or a sequence without name is an error. Otherwise, names without sequence are ignored and sequences without name are appended in their original order. Duplicate names or identifiers are an error.
+func Reorder(seqs []*Sequence, names []string,
+	strict bool) ([]*Sequence, error) {
+	return reorder(seqs, names, strict, (*Sequence).ID)
+}
+
+// ReorderByHeader is like Reorder, except that names are matched against the full headers.
+func ReorderByHeader(seqs []*Sequence, names []string,
+	strict bool) ([]*Sequence, error) {
+	return reorder(seqs, names, strict, (*Sequence).Header)
+}
+func reorder(seqs []*Sequence, names []string, strict bool,
+	key func(*Sequence) string) ([]*Sequence, error) {
+	idx := make(map[string]int)
+	for i, s := range seqs {
+		k := key(s)
+		if _, ok := idx[k]; ok {
+			return nil, fmt.Errorf("%w %q", ErrDuplicateID, k)
+		}
+		idx[k] = i
+	}
+	var res []*Sequence
+	used := make([]bool, len(seqs))
+	named := make(map[string]bool)
+	for _, name := range names {
+		if named[name] {
+			return nil, fmt.Errorf("duplicate name %q", name)
+		}
+		named[name] = true
+		i, ok := idx[name]
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("%w %q",
+					ErrUnknownSequence, name)
+			}
+			continue
+		}
+		res = append(res, seqs[i])
+		used[i] = true
+	}
+	for i, s := range seqs {
+		if used[i] {
+			continue
+		}
+		if strict {
+			return nil, fmt.Errorf("sequence %q not in names", key(s))
+		}
+		res = append(res, s)
+	}
+	return res, nil
+}
+
+// LengthBin returns a function that assigns a sequence to its length bin given the cutoffs in ascending order.
+func LengthBin(cutoffs []int) func(*Sequence) int {
+	return func(s *Sequence) int {
+		return sort.Search(len(cutoffs), func(i int) bool {
+			return cutoffs[i] > s.Length()
+		})
+	}
+}
+
+// GCBin returns a function that assigns a sequence to its GC bin given the cutoffs in ascending order. Empty sequences go into the first bin.
+func GCBin(cutoffs []float64) func(*Sequence) int {
+	return func(s *Sequence) int {
+		if s.Length() == 0 {
+			return 0
+		}
+		gc := s.GC()
+		return sort.Search(len(cutoffs), func(i int) bool {
+			return cutoffs[i] > gc
+		})
+	}
+}
+
+// Partition distributes seqs over n bins using bin, keeping their order within bins.
+func Partition(seqs []*Sequence, n int,
+	bin func(*Sequence) int) [][]*Sequence {
+	bins := make([][]*Sequence, n)
+	for _, s := range seqs {
+		i := bin(s)
+		bins[i] = append(bins[i], s)
+	}
+	return bins
+}
+
+// PartitionByLength distributes seqs over the length bins delimited by cutoffs.
+func PartitionByLength(seqs []*Sequence, cutoffs []int) [][]*Sequence {
+	return Partition(seqs, len(cutoffs)+1, LengthBin(cutoffs))
+}
+
+// PartitionByGC distributes seqs over the GC bins delimited by cutoffs.
+func PartitionByGC(seqs []*Sequence,
+	cutoffs []float64) [][]*Sequence {
+	return Partition(seqs, len(cutoffs)+1, GCBin(cutoffs))
+}
+
+// PartitionStream reads sequences from r and writes each to the writer of its bin in ws. It holds only one sequence in memory at a time.
+func PartitionStream(r io.Reader, ws []io.Writer,
+	bin func(*Sequence) int) error {
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		i := bin(s)
+		if i < 0 || i >= len(ws) {
+			return fmt.Errorf("%q: no writer for bin %d",
+				s.header, i)
+		}
+		if _, err := fmt.Fprintf(ws[i], "%s\n", s); err != nil {
+			return err
+		}
+	}
+	return sc.Err()
+}
+
+// Windows returns the windows of length size that start every step residues along each sequence in seqs. Each window is named id:start-end and keeps the description of its sequence. The last window of a sequence may be shorter than size. Windows panics if size or step is not positive.
+func Windows(seqs []*Sequence, size, step int) []*Sequence {
+	if size <= 0 || step <= 0 {
+		panic("fasta: window size and step must be positive")
+	}
+	var wins []*Sequence
+	for _, s := range seqs {
+		d := s.Data()
+		n := len(d)
+		for start := 0; start < n; start += step {
+			end := start + size
+			if end > n {
+				end = n
+			}
+			h := regionHeader(s, start, end)
+			win := NewSequence(h, d[start:end])
+			win.meta = copyMeta(s.meta)
+			wins = append(wins, win)
+			if end == n {
+				break
+			}
+		}
+	}
+	return wins
+}
+
+// MaxNFraction returns a predicate that is true for sequences where at most the fraction f of residues are N or n.
+func MaxNFraction(f float64) func(*Sequence) bool {
+	return func(s *Sequence) bool {
+		d := s.Data()
+		if len(d) == 0 {
+			return true
+		}
+		c := 0
+		for _, b := range d {
+			if b == 'N' || b == 'n' {
+				c++
+			}
+		}
+		return float64(c)/float64(len(d)) <= f
+	}
+}
+
+// readBED reads the intervals in a BED file.
+func readBED(r io.Reader) ([]bedRecord, error) {
+	var recs []bedRecord
+	lr := &lineReader{r: bufio.NewReader(r)}
+	n := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		n++
+		fields := strings.Fields(string(line))
+		if len(fields) == 0 || fields[0][0] == '#' ||
+			fields[0] == "track" || fields[0] == "browser" {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("bed line %d: want at least 3 "+
+				"columns, get %d", n, len(fields))
+		}
+		start, err1 := strconv.Atoi(fields[1])
+		end, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil || start < 0 || start > end {
+			return nil, fmt.Errorf("bed line %d: invalid interval", n)
+		}
+		rec := bedRecord{chrom: fields[0], start: start, end: end,
+			strand: '+', line: n}
+		if len(fields) > 3 && fields[3] != "." {
+			rec.name = fields[3]
+		}
+		if len(fields) > 5 && fields[5] == "-" {
+			rec.strand = '-'
+		}
+		recs = append(recs, rec)
+	}
+	return recs, lr.err
+}
+
+// ExtractBED extracts the intervals in bed from the sequences in seqs, which are looked up by their ID. Intervals on the minus strand are reverse-complemented. An extracted sequence is named after its interval, or chrom:start-end if the interval has no name. Unknown sequences and intervals reaching past the end of their sequence are errors.
+func ExtractBED(seqs []*Sequence, bed io.Reader) ([]*Sequence, error) {
+	return extractBED(seqs, bed, false)
+}
+
+// ExtractBEDClamped is like ExtractBED, except that intervals reaching past the end of their sequence are clamped to it.
+func ExtractBEDClamped(seqs []*Sequence,
+	bed io.Reader) ([]*Sequence, error) {
+	return extractBED(seqs, bed, true)
+}
+
+// extractBED implements ExtractBED and ExtractBEDClamped.
+func extractBED(seqs []*Sequence, bed io.Reader,
+	clamp bool) ([]*Sequence, error) {
+	recs, err := readBED(bed)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*Sequence)
+	for _, s := range seqs {
+		byID[s.ID()] = s
+	}
+	var out []*Sequence
+	for _, rec := range recs {
+		s, ok := byID[rec.chrom]
+		if !ok {
+			return nil, fmt.Errorf("bed line %d: %w %q",
+				rec.line, ErrUnknownSequence, rec.chrom)
+		}
+		if err := s.Materialize(); err != nil {
+			return nil, fmt.Errorf("bed line %d: %w", rec.line, err)
+		}
+		length := s.Length()
+		name := rec.name
+		if name == "" {
+			name = fmt.Sprintf("%s:%d-%d", rec.chrom, rec.start, rec.end)
+		}
+		start, end := rec.start, rec.end
+		if end > length {
+			if !clamp {
+				return nil, fmt.Errorf("bed line %d: interval %d-%d "+
+					"exceeds length %d of %q", rec.line, start, end,
+					length, rec.chrom)
+			}
+			end = length
+			if start > end {
+				start = end
+			}
+		}
+		x := NewSequence(composeHeader(name, s.Description()),
+			s.Data()[start:end])
+		x.meta = copyMeta(s.meta)
+		if rec.strand == '-' {
+			x.ReverseComplement()
+		}
+		out = append(out, x)
+	}
+	return out, nil
+}
+
+// MaskBED masks the intervals in bed on the sequences in seqs, which are looked up by their ID. Masking is soft, by converting residues to lower case, or hard, by replacing them with N. MaskBED returns the number of residues it changed. Intervals on unknown sequences are skipped and their sequence names reported in the error, as they usually hint at a naming mismatch like chr1 versus 1.
+func MaskBED(seqs []*Sequence, bed io.Reader,
+	soft bool) (maskedBases int, err error) {
+	recs, err := readBED(bed)
+	if err != nil {
+		return 0, err
+	}
+	byID := make(map[string]*Sequence)
+	for _, s := range seqs {
+		byID[s.ID()] = s
+	}
+	var unknown []string
+	seen := make(map[string]bool)
+	for _, rec := range recs {
+		s, ok := byID[rec.chrom]
+		if !ok {
+			if !seen[rec.chrom] {
+				seen[rec.chrom] = true
+				unknown = append(unknown, rec.chrom)
+			}
+			continue
+		}
+		if rec.end > s.Length() {
+			return 0, fmt.Errorf("bed line %d: interval %d-%d "+
+				"exceeds length %d of %q", rec.line, rec.start,
+				rec.end, s.Length(), rec.chrom)
+		}
+		if s.frozen {
+			return 0, fmt.Errorf("bed line %d: %q: %w", rec.line,
+				rec.chrom, ErrFrozen)
+		}
+		if err := s.Materialize(); err != nil {
+			return 0, fmt.Errorf("bed line %d: %w", rec.line, err)
+		}
+	}
+	for _, rec := range recs {
+		s, ok := byID[rec.chrom]
+		if !ok {
+			continue
+		}
+		d := s.Data()
+		for i := rec.start; i < rec.end; i++ {
+			c := d[i]
+			m := byte('N')
+			if soft && c >= 'A' && c <= 'Z' {
+				m = c - 'A' + 'a'
+			} else if soft {
+				m = c
+			}
+			if c != m {
+				d[i] = m
+				maskedBases++
+			}
+		}
+		s.dataChanged()
+	}
+	if len(unknown) > 0 {
+		return maskedBases, fmt.Errorf("unknown sequences in bed: %s",
+			strings.Join(unknown, ", "))
+	}
+	return maskedBases, nil
+}
+
+// RenameFromMap replaces the IDs of the sequences in seqs according to mapping and returns the number of sequences renamed. Descriptions are preserved. In strict mode, IDs in the mapping that match no sequence are an error. The IDs after renaming must be unique, otherwise nothing is renamed and an error is returned.
+func RenameFromMap(seqs []*Sequence, mapping io.Reader,
+	strict bool) (renamed int, err error) {
+	m, err := readMapping(mapping)
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[string]bool)
+	ids := make(map[string]bool)
+	for _, s := range seqs {
+		id := s.ID()
+		if n, ok := m[id]; ok {
+			if s.frozen {
+				return 0, fmt.Errorf("%q: %w", id, ErrFrozen)
+			}
+			used[id] = true
+			id = n
+		}
+		if ids[id] {
+			return 0, fmt.Errorf("%w %q after renaming",
+				ErrDuplicateID, id)
+		}
+		ids[id] = true
+	}
+	if strict {
+		var missing []string
+		for old := range m {
+			if !used[old] {
+				missing = append(missing, old)
+			}
+		}
+		if len(missing) > 0 {
+			sort.Strings(missing)
+			return 0, fmt.Errorf("IDs in mapping without sequence: %s",
+				strings.Join(missing, ", "))
+		}
+	}
+	for _, s := range seqs {
+		if id, ok := m[s.ID()]; ok {
+			s.header = composeHeader(id, s.Description())
+			s.rawHeader = ""
+			renamed++
+		}
+	}
+	return renamed, nil
+}
+
+// readMapping reads a two-column mapping from old to new IDs. Blank lines are skipped; an old ID may appear only once.
+func readMapping(r io.Reader) (map[string]string, error) {
+	m := make(map[string]string)
+	lr := &lineReader{r: bufio.NewReader(r)}
+	n := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		n++
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		fields := strings.Split(string(line), "\t")
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("mapping line %d: want 2 "+
+				"columns", n)
+		}
+		if _, ok := m[fields[0]]; ok {
+			return nil, fmt.Errorf("mapping line %d: duplicate "+
+				"ID %q", n, fields[0])
+		}
+		m[fields[0]] = fields[1]
+	}
+	return m, lr.err
+}
+
+// KmerSpectrum counts the canonical k-mers in all sequences read from r, holding only one sequence in memory at a time. K-mers containing residues other than A, C, G, or T in either case are skipped. It also returns the total and the distinct numbers of k-mers counted. The k-mer length must be between 1 and 32.
+func KmerSpectrum(r io.Reader, k int) (counts map[uint64]uint32,
+	total, distinct int, err error) {
+	if k < 1 || k > 32 {
+		return nil, 0, 0, fmt.Errorf("k-mer length %d not in "+
+			"[1, 32]", k)
+	}
+	counts = make(map[uint64]uint32)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		total += countKmers(sc.Sequence().data, k, counts)
+	}
+	return counts, total, len(counts), sc.Err()
+}
+
+// countKmers adds the canonical k-mers in d to counts and returns their number.
+func countKmers(d []byte, k int, counts map[uint64]uint32) int {
+	n := 0
+	var fwd, rev uint64
+	mask := uint64(1)<<(2*uint(k)) - 1
+	if k == 32 {
+		mask = ^uint64(0)
+	}
+	shift := 2 * uint(k-1)
+	l := 0
+	for _, c := range d {
+		var x uint64
+		switch c {
+		case 'A', 'a':
+			x = 0
+		case 'C', 'c':
+			x = 1
+		case 'G', 'g':
+			x = 2
+		case 'T', 't':
+			x = 3
+		default:
+			l = 0
+			continue
+		}
+		fwd = (fwd<<2 | x) & mask
+		rev = rev>>2 | (3-x)<<shift
+		l++
+		if l >= k {
+			key := fwd
+			if rev < key {
+				key = rev
+			}
+			counts[key]++
+			n++
+		}
+	}
+	return n
+}
+
+// KmerHistogram returns the count of counts of a k-mer spectrum, where element i is the number of k-mers occurring i times.
+func KmerHistogram(counts map[uint64]uint32) []int {
+	max := uint32(0)
+	for _, c := range counts {
+		if c > max {
+			max = c
+		}
+	}
+	h := make([]int, max+1)
+	for _, c := range counts {
+		h[c]++
+	}
+	return h
+}
+
+// FindContained returns for each query the IDs of the subjects that contain it exactly on either strand, ignoring case. Subjects are listed in input order and queries not found are omitted. Candidate subjects are looked up in an index of their k-mers of length minKmer; queries shorter than that are checked against all subjects.
+func FindContained(queries, subjects []*Sequence,
+	minKmer int) map[string][]string {
+	subs := make([][]byte, len(subjects))
+	index := make(map[string][]int)
+	for i, s := range subjects {
+		subs[i] = bytes.ToUpper(s.Data())
+		if minKmer < 1 {
+			continue
+		}
+		for j := 0; j+minKmer <= len(subs[i]); j++ {
+			key := string(subs[i][j : j+minKmer])
+			l := index[key]
+			if len(l) == 0 || l[len(l)-1] != i {
+				index[key] = append(l, i)
+			}
+		}
+	}
+	found := make(map[string][]string)
+	for _, q := range queries {
+		fwd := bytes.ToUpper(q.Data())
+		r := NewSequence("", fwd)
+		r.ReverseComplement()
+		rev := r.data
+		cand := make([]bool, len(subjects))
+		for _, d := range [][]byte{fwd, rev} {
+			if minKmer < 1 || len(d) < minKmer {
+				for i := range cand {
+					cand[i] = true
+				}
+				break
+			}
+			for _, i := range index[string(d[:minKmer])] {
+				cand[i] = true
+			}
+		}
+		for i, c := range cand {
+			if c && (bytes.Contains(subs[i], fwd) ||
+				bytes.Contains(subs[i], rev)) {
+				found[q.ID()] = append(found[q.ID()], subjects[i].ID())
+			}
+		}
+	}
+	return found
+}
+
+// parallelRanges splits the range from 0 to n into at most workers blocks of roughly equal size and calls f on each of them concurrently. It returns when all calls are done.
+func parallelRanges(n, workers int, f func(lo, hi int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		f(0, n)
+		return
+	}
+	var wg sync.WaitGroup
+	size := (n + workers - 1) / workers
+	for lo := 0; lo < n; lo += size {
+		hi := lo + size
+		if hi > n {
+			hi = n
+		}
+		wg.Add(1)
+		go func(lo, hi int) {
+			defer wg.Done()
+			f(lo, hi)
+		}(lo, hi)
+	}
+	wg.Wait()
+}
+
+// BuildFai indexes the FASTA data read from r. Sequences are named by the first word of their header.
+func BuildFai(r io.Reader) ([]FaiEntry, error) {
+	entries, _, err := indexFasta(r, false)
+	return entries, err
+}
+
+// indexFasta indexes the FASTA data read from r and, if keepHeaders is set, also returns the full headers.
+func indexFasta(r io.Reader, keepHeaders bool) ([]FaiEntry,
+	[]string, error) {
+	var entries []FaiEntry
+	var headers []string
+	br := bufio.NewReader(r)
+	seen := make(map[string]bool)
+	var off int64
+	short := false
+	n := 0
+	for {
+		line, err := br.ReadSlice('\n')
+		width := len(line)
+		isHeader := width > 0 && line[0] == '>'
+		var header []byte
+		if isHeader {
+			header = append(header, line...)
+		}
+		var prev byte
+		for err == bufio.ErrBufferFull {
+			prev = line[len(line)-1]
+			line, err = br.ReadSlice('\n')
+			width += len(line)
+			if isHeader {
+				header = append(header, line...)
+			}
+		}
+		if err != nil && err != io.EOF {
+			return nil, nil, err
+		}
+		bases := width
+		if k := len(line); k > 0 && line[k-1] == '\n' {
+			bases--
+			if (k > 1 && line[k-2] == '\r') || (k == 1 && prev == '\r') {
+				bases--
+			}
+		}
+		if width == 0 {
+			break
+		}
+		n++
+		if isHeader {
+			s := NewSequence(strings.TrimSpace(string(header[1:])), nil)
+			name := s.ID()
+			if seen[name] {
+				return nil, nil, fmt.Errorf("line %d: %w %q", n,
+					ErrDuplicateID, name)
+			}
+			seen[name] = true
+			if keepHeaders {
+				headers = append(headers, s.header)
+			}
+			entries = append(entries, FaiEntry{Name: name,
+				Offset: off + int64(width)})
+			short = false
+		} else if len(entries) == 0 {
+			if bases > 0 {
+				return nil, nil, fmt.Errorf("line %d: %w", n,
+					ErrNoHeader)
+			}
+		} else {
+			e := &entries[len(entries)-1]
+			if bases == 0 {
+				short = true
+			} else if short || (e.LineBases > 0 && (bases > e.LineBases ||
+				width-bases != e.LineWidth-e.LineBases)) {
+				return nil, nil, fmt.Errorf("line %d: sequence %q has lines of "+
+					"different length", n, e.Name)
+			} else {
+				if e.LineBases == 0 {
+					e.LineBases, e.LineWidth = bases, width
+				} else if bases < e.LineBases {
+					short = true
+				}
+				e.Length += bases
+			}
+		}
+		off += int64(width)
+		if err == io.EOF {
+			break
+		}
+	}
+	return entries, headers, nil
+}
+
+// ReadFai reads a FASTA index.
+func ReadFai(r io.Reader) ([]FaiEntry, error) {
+	var entries []FaiEntry
+	lr := &lineReader{r: bufio.NewReader(r)}
+	n := 0
+	for {
+		line, ok := lr.nextLine()
+		if !ok {
+			break
+		}
+		n++
+		fields := strings.Split(string(line), "\t")
+		if len(fields) < 5 {
+			return nil, fmt.Errorf("fai line %d: want 5 "+
+				"columns, get %d", n, len(fields))
+		}
+		var e FaiEntry
+		var errs [4]error
+		e.Name = fields[0]
+		e.Length, errs[0] = strconv.Atoi(fields[1])
+		e.Offset, errs[1] = strconv.ParseInt(fields[2], 10, 64)
+		e.LineBases, errs[2] = strconv.Atoi(fields[3])
+		e.LineWidth, errs[3] = strconv.Atoi(fields[4])
+		for _, err := range errs {
+			if err != nil {
+				return nil, fmt.Errorf("fai line %d: %w", n, err)
+			}
+		}
+		entries = append(entries, e)
+	}
+	return entries, lr.err
+}
+
+// WriteFai writes a FASTA index.
+func WriteFai(w io.Writer, entries []FaiEntry) error {
+	bw := bufio.NewWriter(w)
+	for _, e := range entries {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", e.Name, e.Length,
+			e.Offset, e.LineBases, e.LineWidth)
+	}
+	return bw.Flush()
+}
+
+// NewFaidx returns a Faidx that fetches regions from r using the index entries.
+func NewFaidx(r io.ReaderAt, entries []FaiEntry) *Faidx {
+	f := &Faidx{r: r, entries: entries}
+	f.index = make(map[string]int)
+	for i, e := range entries {
+		f.index[e.Name] = i
+	}
+	return f
+}
+
+// OpenFaidx opens the FASTA file path for indexed access. It reads the index from path.fai; if there is none, it builds the index and tries to save it there. Input that isn't a regular file, like the standard input, can't be indexed.
+func OpenFaidx(path string) (*Faidx, error) {
+	if path == "-" {
+		return nil, fmt.Errorf("stdin: %w", ErrNotSeekable)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeekable(path, f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	entries, err := loadFai(path, f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	fx := NewFaidx(f, entries)
+	fx.closer = f.Close
+	return fx, nil
+}
+
+// loadFai reads the index of the FASTA file path from path.fai, or builds it from f. A newly built index is saved if possible; failing that isn't an error, as the index also works from memory.
+func loadFai(path string, f *os.File) ([]FaiEntry, error) {
+	if fai, err := os.Open(path + ".fai"); err == nil {
+		defer fai.Close()
+		return ReadFai(fai)
+	}
+	entries, err := BuildFai(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if fai, err := os.Create(path + ".fai"); err == nil {
+		err = WriteFai(fai, entries)
+		fai.Close()
+		if err != nil {
+			os.Remove(path + ".fai")
+		}
+	}
+	return entries, nil
+}
+
+// OpenMmap opens the FASTA file path for indexed access via a memory map. The index is loaded as in OpenFaidx. On platforms without memory maps, it falls back to reading the file.
+func OpenMmap(path string) (*MmapFasta, error) {
+	fx, err := OpenFaidx(path)
+	if err != nil {
+		return nil, err
+	}
+	f := fx.r.(*os.File)
+	fi, err := f.Stat()
+	if err != nil {
+		fx.Close()
+		return nil, err
+	}
+	data, err := mmapFile(f, int(fi.Size()))
+	if err != nil {
+		return &MmapFasta{Faidx: fx}, nil
+	}
+	f.Close()
+	fx.r = bytes.NewReader(data)
+	fx.closer = func() error {
+		return munmap(data)
+	}
+	return &MmapFasta{Faidx: fx, mapped: true}, nil
+}
+
+// ReadAllLazy reads the headers of the sequences in the file path, but defers reading their data until it is needed. Length is answered without loading the data, while Data and the methods that read or change the data load it first. They panic if the data cannot be loaded, so call Materialize to handle such errors. Functions of this package that take sequences and return an error, like Concatenate, WriteTab, or ExtractBED, return it instead; the others, like Stats or Windows, panic.
+func ReadAllLazy(path string) ([]*Sequence, error) {
+	if path == "-" {
+		return nil, fmt.Errorf("stdin: %w", ErrNotSeekable)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSeekable(path, f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	entries, headers, err := indexFasta(f, true)
+	f.Close()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	fx := NewFaidx(pathReaderAt(path), entries)
+	seqs := make([]*Sequence, len(entries))
+	for i, e := range entries {
+		seqs[i] = &Sequence{header: headers[i],
+			lineLength: DefaultLineLength}
+		seqs[i].lazy = &lazyData{fx: fx, name: e.Name,
+			length: e.Length}
+	}
+	return seqs, nil
+}
+
+// Recycle returns s to the pool used by SequencePooled. Afterwards, neither s nor its data may be used any more, as both will be handed out again.
+func Recycle(s *Sequence) {
+	s.mustBeMutable()
+	s.header = ""
+	s.rawHeader = ""
+	s.data = s.data[:0]
+	s.lazy = nil
+	s.meta = nil
+	s.dataChanged()
+	sequencePool.Put(s)
+}
+
+// NewBuilder returns a new Builder for a Sequence with header h.
+func NewBuilder(h string) *Builder {
+	return &Builder{header: h}
+}
+
+// NewDataReader returns a reader of the residues of s. The reader shares the data of s, which should therefore not be changed while the reader is in use.
+func NewDataReader(s *Sequence) io.ReadSeeker {
+	return bytes.NewReader(s.Data())
+}
+
+// NewFastaReader returns a reader of s in FASTA format, including header, line breaks, and a final newline. Like the reader returned by NewDataReader, it shares the data of s.
+func NewFastaReader(s *Sequence) io.ReadSeeker {
+	d := s.Data()
+	r := &fastaReader{s: s, l: s.lineLength}
+	r.head = []byte(">" + s.header + "\n")
+	if r.l < 1 {
+		r.l = len(d)
+	}
+	return r
+}
+
+// formatThousands formats n with commas between groups of three digits.
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	sign := ""
+	if n < 0 {
+		sign, s = "-", s[1:]
+	}
+	var b strings.Builder
+	for i, c := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String()
+}
+
+// rulerPad returns the width of the position column for a sequence of length n.
+func rulerPad(n int) int {
+	pad := len(strconv.Itoa(n))
+	if pad < 7 {
+		pad = 7
+	}
+	return pad
+}
+
+// copyMeta returns a shallow copy of the metadata map m, or nil if m is empty.
+func copyMeta(m map[string]interface{}) map[string]interface{} {
+	if len(m) == 0 {
+		return nil
+	}
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+// Format writes s in FASTA format to w, with data lines of length wrap, followed by a newline. A wrap of NoWrap writes the data in a single line, KeepLineLength uses the line length of s. Any other negative wrap is an error wrapping ErrLineLength.
+func Format(w io.Writer, s *Sequence, wrap int) error {
+	bw := bufio.NewWriter(w)
+	if err := format(bw, s, wrap, false); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// format writes s to the buffered writer bw with line length wrap. If raw is set, the raw header is written if there is one.
+func format(bw *bufio.Writer, s *Sequence, wrap int, raw bool) error {
+	if err := s.Materialize(); err != nil {
+		return err
+	}
+	if wrap == KeepLineLength {
+		wrap = s.lineLength
+	} else if err := checkLineLength(wrap); err != nil {
+		return err
+	}
+	d := s.data
+	if wrap < 1 {
+		wrap = len(d)
+	}
+	h := s.header
+	if raw && s.rawHeader != "" {
+		h = s.rawHeader
+	}
+	bw.WriteByte('>')
+	bw.WriteString(h)
+	bw.WriteByte('\n')
+	for i := 0; i < len(d); i += wrap {
+		j := i + wrap
+		if j > len(d) {
+			j = len(d)
+		}
+		bw.Write(d[i:j])
+		bw.WriteByte('\n')
+	}
+	_, err := bw.Write(nil)
+	return err
+}
+
+// NewWriter returns a Writer to w that wraps lines after wrap residues. A wrap of NoWrap writes each sequence in a single line, KeepLineLength keeps the line length of each sequence written. Any other negative wrap makes Write return an error wrapping ErrLineLength. The Writer is configured by opts.
+func NewWriter(w io.Writer, wrap int, opts ...WriterOption) *Writer {
+	fw := &Writer{w: bufio.NewWriter(w), lineLength: wrap}
+	for _, opt := range opts {
+		opt(fw)
+	}
+	return fw
+}
+
+// WithPreserveWrapping sets the line length of each scanned sequence to the length of its first data line, so that writing it reproduces the wrapping of the input. Whether the input lines of the last sequence were wrapped consistently is reported by WrapInconsistent.
+func WithPreserveWrapping() ScannerOption {
+	return func(s *Scanner) {
+		s.preserveWrap = true
+	}
+}
+
+// nucMask returns the set of nucleotides denoted by n as a bit mask, where T, C, A, and G are bits 0 to 3. Characters that aren't nucleotides give zero.
+func nucMask(n byte) byte {
+	const t, c, a, g = 1, 2, 4, 8
+	switch upper(n) {
+	case 'T', 'U':
+		return t
+	case 'C':
+		return c
+	case 'A':
+		return a
+	case 'G':
+		return g
+	case 'R':
+		return a | g
+	case 'Y':
+		return c | t
+	case 'S':
+		return c | g
+	case 'W':
+		return a | t
+	case 'K':
+		return g | t
+	case 'M':
+		return a | c
+	case 'B':
+		return c | g | t
+	case 'D':
+		return a | g | t
+	case 'H':
+		return a | c | t
+	case 'V':
+		return a | c | g
+	case 'N':
+		return a | c | g | t
+	}
+	return 0
+}
+
+// codonIndex returns the index of codon c in a translation table, which is made up of the masks of its three nucleotides.
+func codonIndex(c []byte) int {
+	return int(nucMask(c[0]))<<8 | int(nucMask(c[1]))<<4 |
+		int(nucMask(c[2]))
+}
+
+// translationTable returns the translation table of the NCBI genetic code id.
+func translationTable(id int) (*[4096]byte, error) {
+	transMu.Lock()
+	defer transMu.Unlock()
+	if tt, ok := transTables[id]; ok {
+		return tt, nil
+	}
+	code, ok := geneticCodes[id]
+	if !ok {
+		return nil, fmt.Errorf("unknown genetic code %d", id)
+	}
+	tt := new([4096]byte)
+	for m := range tt {
+		m1, m2, m3 := m>>8, m>>4&15, m&15
+		aa := byte(0)
+		for i := 0; i < 4; i++ {
+			for j := 0; j < 4; j++ {
+				for k := 0; k < 4; k++ {
+					if m1>>i&1 == 0 || m2>>j&1 == 0 ||
+						m3>>k&1 == 0 {
+						continue
+					}
+					x := code[16*i+4*j+k]
+					if aa == 0 {
+						aa = x
+					} else if aa != x {
+						aa = 'X'
+					}
+				}
+			}
+		}
+		if aa == 0 {
+			aa = 'X'
+		}
+		tt[m] = aa
+	}
+	transTables[id] = tt
+	return tt, nil
+}
+
+// CodonWeights returns the weights of the codons in NCBI genetic code table derived from the coding sequences in referenceCDS, usually highly expressed genes. The weight of a codon is its count relative to the count of the most frequent codon for the same amino acid. Only codons of amino acids with more than one codon are weighted.
+func CodonWeights(referenceCDS []*Sequence,
+	table int) (map[string]float64, error) {
+	if _, ok := geneticCodes[table]; !ok {
+		return nil, fmt.Errorf("unknown genetic code %d", table)
+	}
+	counts := make(map[string]float64)
+	for _, s := range referenceCDS {
+		if err := s.Materialize(); err != nil {
+			return nil, err
+		}
+		if len(s.data)%3 != 0 {
+			return nil, fmt.Errorf("%q: length %d not a "+
+				"multiple of three", s.header, len(s.data))
+		}
+		for i := 0; i < len(s.data); i += 3 {
+			if c, ok := concreteCodon(s.data[i : i+3]); ok {
+				counts[c]++
+			}
+		}
+	}
+	syn := synonymousCodons(table)
+	w := make(map[string]float64)
+	for _, codons := range syn {
+		max := 0.0
+		for _, c := range codons {
+			if counts[c] > max {
+				max = counts[c]
+			}
+		}
+		for _, c := range codons {
+			w[c] = (counts[c] + 0.5) / (max + 0.5)
+		}
+	}
+	return w, nil
+}
+
+// synonymousCodons returns the codons of NCBI genetic code table grouped by amino acid, omitting stops and amino acids with a single codon.
+func synonymousCodons(table int) map[byte][]string {
+	const nuc = "TCAG"
+	code := geneticCodes[table]
+	syn := make(map[byte][]string)
+	for i := 0; i < 64; i++ {
+		aa := code[i]
+		if aa == '*' {
+			continue
+		}
+		c := string([]byte{nuc[i/16], nuc[i/4%4], nuc[i%4]})
+		syn[aa] = append(syn[aa], c)
+	}
+	for aa, codons := range syn {
+		if len(codons) < 2 {
+			delete(syn, aa)
+		}
+	}
+	return syn
+}
+
+// concreteCodon returns codon c in upper case with U replaced by T, and whether it consists only of A, C, G, and T.
+func concreteCodon(c []byte) (string, bool) {
+	b := make([]byte, 3)
+	for i, x := range c {
+		x = upper(x)
+		if x == 'U' {
+			x = 'T'
+		}
+		if x != 'A' && x != 'C' && x != 'G' && x != 'T' {
+			return "", false
+		}
+		b[i] = x
+	}
+	return string(b), true
+}
+
+// gcSkew returns the contribution of residue c to the GC skew.
+func gcSkew(c byte) int {
+	switch c {
+	case 'G', 'g':
+		return 1
+	case 'C', 'c':
+		return -1
+	}
+	return 0
+}
+
+// tandemRepeats returns the tandem repeats in d with unit length p and at least minCopies copies, sorted by start.
+func tandemRepeats(d []byte, p, minCopies int) []TandemRepeat {
+	var reps []TandemRepeat
+	a := 0
+	for i := 0; i+p <= len(d); i++ {
+		if i+p < len(d) && sameNuc(d[i], d[i+p]) {
+			continue
+		}
+		if c := (i - a + p) / p; c >= minCopies {
+			u := string(d[a : a+p])
+			if isPrimitive(strings.ToUpper(u)) {
+				reps = append(reps, TandemRepeat{Start: a,
+					End: a + c*p, Unit: u, Copies: c})
+			}
+		}
+		a = i + 1
+	}
+	return reps
+}
+
+// sortRepeats sorts tandem repeats by start and unit length.
+func sortRepeats(reps []TandemRepeat) {
+	sort.Slice(reps, func(i, j int) bool {
+		if reps[i].Start != reps[j].Start {
+			return reps[i].Start < reps[j].Start
+		}
+		return len(reps[i].Unit) < len(reps[j].Unit)
+	})
+}
+
+// sameNuc reports whether a and b are the same unambiguous nucleotide, regardless of case.
+func sameNuc(a, b byte) bool {
+	a, b = upper(a), upper(b)
+	return a == b && (a == 'A' || a == 'C' || a == 'G' || a == 'T')
+}
+
+// isPrimitive reports whether u isn't itself made up of copies of a shorter unit.
+func isPrimitive(u string) bool {
+	return strings.Index((u + u)[1:], u)+1 == len(u)
+}
+
+// repeatCoverage returns the number of residues in d that lie in tandem repeats of at least two copies of unit starting at any of its positions.
+func repeatCoverage(d []byte, unit string) int {
+	p := len(unit)
+	uu := strings.ToUpper(unit + unit)
+	covered, last := 0, 0
+	a := 0
+	for i := 0; i+p <= len(d); i++ {
+		if i+p < len(d) && sameNuc(d[i], d[i+p]) {
+			continue
+		}
+		first := strings.ToUpper(string(d[a : a+p]))
+		if i-a >= p && strings.Contains(uu, first) {
+			start := a
+			if start < last {
+				start = last
+			}
+			covered += i + p - start
+			last = i + p
+		}
+		a = i + 1
+	}
+	return covered
+}
+
+// WriteTelomereReport writes for each sequence in seqs its ID, length, and telomere content at the 5' and 3' end, as returned by TelomereContent, to w, starting with TelomereReportHeader.
+func WriteTelomereReport(w io.Writer, seqs []*Sequence, unit string,
+	window int) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, TelomereReportHeader)
+	for _, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return err
+		}
+		f, t := s.TelomereContent(unit, window)
+		fmt.Fprintf(bw, "%s\t%d\t%.4f\t%.4f\n", s.ID(), s.Length(),
+			f, t)
+	}
+	return bw.Flush()
+}
+
+// FindSTRs returns the perfect short tandem repeats in seqs with the unit lengths in unitSizes that are at least minLen residues long and consist of at least two complete copies. The chromosome of a repeat is the ID of its sequence, its unit is in upper case, so soft-masking doesn't matter. The repeats are sorted by sequence, start, and unit length. FindSTRs panics if a unit length isn't positive.
+func FindSTRs(seqs []*Sequence, unitSizes []int, minLen int) []STR {
+	var strs []STR
+	for _, s := range seqs {
+		strs = append(strs, findSTRs(s, unitSizes, minLen)...)
+	}
+	return strs
+}
+
+// findSTRs returns the short tandem repeats in a single sequence.
+func findSTRs(s *Sequence, unitSizes []int, minLen int) []STR {
+	s.mustLoad()
+	var reps []TandemRepeat
+	for _, p := range unitSizes {
+		if p < 1 {
+			panic("fasta: unit length must be positive")
+		}
+		for _, r := range tandemRepeats(s.data, p, 2) {
+			if r.End-r.Start >= minLen {
+				reps = append(reps, r)
+			}
+		}
+	}
+	sortRepeats(reps)
+	strs := make([]STR, len(reps))
+	id := s.ID()
+	for i, r := range reps {
+		strs[i] = STR{Chrom: id, Start: r.Start, End: r.End,
+			Unit: strings.ToUpper(r.Unit), Copies: r.Copies}
+	}
+	return strs
+}
+
+// WriteSTRs writes strs to w in BED format with the unit and copy number as name, as in (CA)12.
+func WriteSTRs(w io.Writer, strs []STR) error {
+	bw := bufio.NewWriter(w)
+	for _, r := range strs {
+		fmt.Fprintf(bw, "%s\t%d\t%d\t(%s)%d\n", r.Chrom, r.Start,
+			r.End, r.Unit, r.Copies)
+	}
+	return bw.Flush()
+}
+
+// FindSTRsStream reads sequences from r and writes their short tandem repeats as found by FindSTRs to w in the format of WriteSTRs, holding only one sequence in memory at a time.
+func FindSTRsStream(r io.Reader, w io.Writer, unitSizes []int,
+	minLen int) error {
+	bw := bufio.NewWriter(w)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		strs := findSTRs(sc.Sequence(), unitSizes, minLen)
+		if err := WriteSTRs(bw, strs); err != nil {
+			return err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// NewSketch returns the MinHash sketch of the canonical k-mers of length k in all of seqs, for example the contigs of an assembly, with at most size hashes computed with seed. K-mers containing residues other than A, C, G, and T are skipped. The same input and seed always give the same sketch. NewSketch panics if k isn't between 1 and 32 or size isn't positive.
+func NewSketch(seqs []*Sequence, k, size int,
+	seed uint64) *MinHashSketch {
+	if k < 1 || k > 32 || size < 1 {
+		panic("fasta: invalid k-mer length or sketch size")
+	}
+	var buf []uint64
+	full, thr := false, uint64(0)
+	for _, s := range seqs {
+		s.mustLoad()
+		d := s.data
+		var fwd, rev uint64
+		mask := uint64(1)<<(2*uint(k)) - 1
+		if k == 32 {
+			mask = ^uint64(0)
+		}
+		shift := 2 * uint(k-1)
+		l := 0
+		for _, c := range d {
+			var x uint64
+			switch c {
+			case 'A', 'a':
+				x = 0
+			case 'C', 'c':
+				x = 1
+			case 'G', 'g':
+				x = 2
+			case 'T', 't':
+				x = 3
+			default:
+				l = 0
+				continue
+			}
+			fwd = (fwd<<2 | x) & mask
+			rev = rev>>2 | (3-x)<<shift
+			l++
+			if l >= k {
+				key := fwd
+				if rev < key {
+					key = rev
+				}
+				h := mixHash(key, seed)
+				if !full || h < thr {
+					buf = append(buf, h)
+					if len(buf) >= 2*size {
+						buf = bottomHashes(buf, size)
+						if len(buf) >= size {
+							full, thr = true, buf[len(buf)-1]
+						}
+					}
+				}
+			}
+		}
+	}
+	buf = bottomHashes(buf, size)
+	return &MinHashSketch{k: k, size: size, seed: seed, hashes: buf}
+}
+
+// bottomHashes sorts h and returns its n smallest distinct values.
+func bottomHashes(h []uint64, n int) []uint64 {
+	sort.Slice(h, func(i, j int) bool { return h[i] < h[j] })
+	j := 0
+	for i, x := range h {
+		if i == 0 || x != h[j-1] {
+			h[j] = x
+			j++
+		}
+	}
+	if j > n {
+		j = n
+	}
+	return h[:j]
+}
+
+// mixHash returns the hash of k-mer code x under seed.
+func mixHash(x, seed uint64) uint64 {
+	x += seed + 0x9e3779b97f4a7c15
+	x = (x ^ x>>30) * 0xbf58476d1ce4e5b9
+	x = (x ^ x>>27) * 0x94d049bb133111eb
+	return x ^ x>>31
+}
+
+// randOrDefault returns r, or the package-default source if r is nil.
+func randOrDefault(r *rand.Rand) *rand.Rand {
+	if r == nil {
+		return defaultRand
+	}
+	return r
+}
+
+// SeededRand returns a new rand.Rand seeded with seed, which makes randomized functions reproducible.
+func SeededRand(seed int64) *rand.Rand {
+	return rand.New(rand.NewSource(seed))
+}
+
+// ShuffleAll randomizes the order of seqs in place, leaving the sequences themselves unchanged. If r is nil, a package-default source of randomness is used.
+func ShuffleAll(seqs []*Sequence, r *rand.Rand) {
+	randOrDefault(r).Shuffle(len(seqs), func(i, j int) {
+		seqs[i], seqs[j] = seqs[j], seqs[i]
+	})
+}
+
+// ReadStdin reads all sequences from the standard input. Gzipped input is decompressed.
+func ReadStdin() ([]*Sequence, error) {
+	return readAll(os.Stdin, 0)
+}
+
+// readAll reads all sequences from r using the capacity hint size.
+func readAll(r io.Reader, size int) ([]*Sequence, error) {
+	br := bufio.NewReader(r)
+	gz, err := isGzip(br)
+	if err != nil {
+		return nil, err
+	}
+	r = br
+	if gz {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		defer zr.Close()
+		r = zr
+		size = 0
+	}
+	sc := NewScanner(r, WithCapacityHint(size), WithHandoff())
+	var seqs []*Sequence
+	for sc.ScanSequence() {
+		seqs = append(seqs, sc.Sequence())
+	}
+	return seqs, sc.Err()
+}
+
+// isGzip reports whether the input buffered in br starts with the gzip magic bytes.
+func isGzip(br *bufio.Reader) (bool, error) {
+	b, err := br.Peek(2)
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+	return len(b) == 2 && b[0] == 0x1f && b[1] == 0x8b, nil
+}
+
+// WriteFile writes the sequences to the file name, or to the standard output if name is “-”. Each sequence keeps its line length.
+func WriteFile(name string, seqs []*Sequence) error {
+	if name == "-" {
+		return writeAll(os.Stdout, seqs)
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	err = writeAll(f, seqs)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeAll writes the sequences to w.
+func writeAll(w io.Writer, seqs []*Sequence) error {
+	fw := NewWriter(w, KeepLineLength)
+	for _, s := range seqs {
+		if err := fw.Write(s); err != nil {
+			return err
+		}
+	}
+	return fw.Flush()
+}
+
+// checkSeekable returns an error wrapping ErrNotSeekable unless f is a regular file.
+func checkSeekable(path string, f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s: %w", path, ErrNotSeekable)
+	}
+	return nil
+}
+
+// ReadURL reads all sequences from the HTTP(S) url. The response is streamed, and decompressed if gzipped. The download stops when ctx is cancelled. A status other than 200 is returned as *HTTPError.
+func ReadURL(ctx context.Context, url string) ([]*Sequence, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPError{URL: url,
+			StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+	return readResponse(ctx, resp, url)
+}
+
+// readResponse reads all sequences from the body of the successful response resp to a request for url.
+func readResponse(ctx context.Context, resp *http.Response,
+	url string) ([]*Sequence, error) {
+	var r io.Reader = resp.Body
+	if !resp.Uncompressed &&
+		resp.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", url, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	seqs, err := readAll(r, 0)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", url, err)
+	}
+	return seqs, nil
+}
+
+// FetchNCBI fetches the sequences with the given accessions from the NCBI database db, for example “nuccore” or “protein”. The accessions are requested in batches, within the rate limits of the NCBI, which are higher if apiKey isn't empty. Transient failures are retried. If accessions are missing from the response, the sequences found are returned with a *MissingAccessionsError.
+func FetchNCBI(ctx context.Context, db string, accessions []string,
+	apiKey string) ([]*Sequence, error) {
+	var seqs []*Sequence
+	for i := 0; i < len(accessions); i += ncbiBatchSize {
+		j := i + ncbiBatchSize
+		if j > len(accessions) {
+			j = len(accessions)
+		}
+		batch, err := fetchNCBIBatch(ctx, db, accessions[i:j],
+			apiKey)
+		if err != nil {
+			return nil, err
+		}
+		seqs = append(seqs, batch...)
+	}
+	found := make(map[string]bool)
+	for _, s := range seqs {
+		id := s.ID()
+		found[id] = true
+		if i := strings.LastIndexByte(id, '.'); i > 0 {
+			found[id[:i]] = true
+		}
+	}
+	var missing []string
+	for _, a := range accessions {
+		if !found[a] {
+			missing = append(missing, a)
+		}
+	}
+	if len(missing) > 0 {
+		return seqs, &MissingAccessionsError{Accessions: missing}
+	}
+	return seqs, nil
+}
+
+// fetchNCBIBatch fetches one batch of accessions.
+func fetchNCBIBatch(ctx context.Context, db string, accessions []string,
+	apiKey string) ([]*Sequence, error) {
+	form := neturl.Values{}
+	form.Set("db", db)
+	form.Set("id", strings.Join(accessions, ","))
+	form.Set("rettype", "fasta")
+	form.Set("retmode", "text")
+	if apiKey != "" {
+		form.Set("api_key", apiKey)
+	}
+	body := form.Encode()
+	backoff := ncbiBackoff
+	for try := 0; ; try++ {
+		interval := time.Second / 3
+		if apiKey != "" {
+			interval = time.Second / 10
+		}
+		if err := ncbiWait(ctx, interval); err != nil {
+			return nil, err
+		}
+		req, err := http.NewRequestWithContext(ctx, "POST", ncbiEfetchURL,
+			strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := http.DefaultClient.Do(req)
+		if err == nil && resp.StatusCode != http.StatusOK {
+			err = &HTTPError{URL: ncbiEfetchURL,
+				StatusCode: resp.StatusCode, Status: resp.Status}
+			resp.Body.Close()
+			c := resp.StatusCode
+			if c != http.StatusTooManyRequests && c < 500 {
+				return nil, err
+			}
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			if try == ncbiRetries {
+				return nil, err
+			}
+			if err := sleepContext(ctx, backoff); err != nil {
+				return nil, err
+			}
+			backoff *= 2
+			continue
+		}
+		defer resp.Body.Close()
+		return readResponse(ctx, resp, ncbiEfetchURL)
+	}
+}
+
+// ncbiWait waits until interval has passed since the last request to the NCBI and then records the time of the new request.
+func ncbiWait(ctx context.Context, interval time.Duration) error {
+	ncbiMu.Lock()
+	defer ncbiMu.Unlock()
+	if err := sleepContext(ctx,
+		time.Until(ncbiLast.Add(interval))); err != nil {
+		return err
+	}
+	ncbiLast = time.Now()
+	return nil
+}
+
+// sleepContext sleeps for d or until ctx is cancelled, in which case it returns the context's error.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WithRawHeaders makes the Scanner keep each header line verbatim, apart from the leading '>' and the line ending. The header is returned by RawHeader.
+func WithRawHeaders() ScannerOption {
+	return func(s *Scanner) {
+		s.rawHeaders = true
+	}
+}
+
+// WithRawHeaderOutput makes the Writer write the raw header of a sequence instead of its header, if it has one. Together with WithRawHeaders, WithPreserveWrapping, and KeepLineLength, this reproduces consistently wrapped input.
+func WithRawHeaderOutput() WriterOption {
+	return func(w *Writer) {
+		w.rawHeaders = true
+	}
+}
+
+// residueTable marks residues in a table of all bytes.
+func residueTable(residues []byte) *[256]bool {
+	var t [256]bool
+	for _, r := range residues {
+		t[r] = true
+	}
+	return &t
+}
+
+// countIn returns the number of bytes in d marked in t.
+func countIn(d []byte, t *[256]bool) int {
+	n := 0
+	for _, c := range d {
+		if t[c] {
+			n++
+		}
+	}
+	return n
+}
+
+// IsAmbiguous reports whether c is one of the IUPAC ambiguity codes for nucleotides, including N, in either case.
+func IsAmbiguous(c byte) bool {
+	return strings.IndexByte("RYSWKMBDHVNryswkmbdhvn", c) >= 0
+}
+
+// CollapseToIUPAC returns the sequence of the IUPAC codes that cover the nucleotides at each position of the equal-length sequences in seqs. The codes are uppercase, and the header is “consensus”. Ambiguity codes in the input contribute all the nucleotides they stand for. Gaps and other residues that aren't nucleotides are errors, and so is input that isn't an alignment according to VerifyAlignment.
+func CollapseToIUPAC(seqs []*Sequence) (*Sequence, error) {
+	if err := VerifyAlignment(seqs); err != nil {
+		return nil, err
+	}
+	n := seqs[0].Length()
+	m := make([]byte, n)
+	for _, s := range seqs {
+		for i, c := range s.Data() {
+			v := nucMask(c)
+			if v == 0 {
+				return nil, fmt.Errorf("%q: residue %q at %d isn't a "+
+					"nucleotide", s.ID(), c, i)
+			}
+			m[i] |= v
+		}
+	}
+	for i, v := range m {
+		m[i] = iupacCodes[v]
+	}
+	return &Sequence{header: "consensus", data: m,
+		lineLength: DefaultLineLength}, nil
+}
+
+// countLower sums the counts of the lowercase letters in the residue counts t.
+func countLower(t *[256]uint64) uint64 {
+	n := uint64(0)
+	for r := 'a'; r <= 'z'; r++ {
+		n += t[r]
+	}
+	return n
+}
+
+// WriteBedGraph writes a bedGraph track of the statistic stat computed over windows of length window that start every step residues along each sequence. Intervals are zero-based and half-open, values have four decimals. Where windows overlap, each value covers the first step residues of its window. The last window of a sequence may be shorter than window. stat is passed windows that alias the data of the sequences and must not keep or change them.
+func WriteBedGraph(w io.Writer, seqs []*Sequence, window, step int,
+	stat func(*Sequence) float64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "track type=bedGraph")
+	for _, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return err
+		}
+		id := s.ID()
+		windowStats(s, window, step, stat,
+			func(start, end int, v float64) {
+				fmt.Fprintf(bw, "%s\t%d\t%d\t%.4f\n", id,
+					start, end, v)
+			})
+	}
+	return bw.Flush()
+}
+
+// WriteWiggle is like WriteBedGraph, but writes a fixed-step wiggle track.
+func WriteWiggle(w io.Writer, seqs []*Sequence, window, step int,
+	stat func(*Sequence) float64) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "track type=wiggle_0")
+	for _, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return err
+		}
+		id := s.ID()
+		next, span := -1, 0
+		windowStats(s, window, step, stat,
+			func(start, end int, v float64) {
+				if start != next || end-start != span {
+					span = end - start
+					fmt.Fprintf(bw, "fixedStep chrom=%s start=%d "+
+						"step=%d span=%d\n", id, start+1, step,
+						span)
+				}
+				next = start + step
+				fmt.Fprintf(bw, "%.4f\n", v)
+			})
+	}
+	return bw.Flush()
+}
+
+// windowStats computes stat over the windows of s and passes each value to f with the interval it covers.
+func windowStats(s *Sequence, window, step int,
+	stat func(*Sequence) float64, f func(int, int, float64)) {
+	n := s.Length()
+	s.WindowsIter(window, step, true, func(i int, d []byte) bool {
+		win := &Sequence{header: s.header, data: d,
+			lineLength: s.lineLength}
+		end := i + len(d)
+		if step < len(d) && end < n {
+			end = i + step
+		}
+		f(i, end, stat(win))
+		return true
+	})
+}
+
+// WriteManifest reads the FASTA input r and writes a line of ID, length, and MD5 sum of the normalized residues for each record to w. Normalized residues are uppercase without gaps, - and ., and the length is theirs.
+func WriteManifest(w io.Writer, r io.Reader) error {
+	bw := bufio.NewWriter(w)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		n, sum := upperSum(s, true)
+		fmt.Fprintf(bw, "%s\t%d\t%x\n", s.ID(), n, sum)
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// upperSum returns the length and the MD5 sum of the residues of s converted to uppercase, without gaps if noGaps is set.
+func upperSum(s *Sequence, noGaps bool) (int, [md5.Size]byte) {
+	h := md5.New()
+	var buf [4096]byte
+	b := buf[:0]
+	n := 0
+	for _, c := range s.Data() {
+		if noGaps && (c == '-' || c == '.') {
+			continue
+		}
+		b = append(b, upper(c))
+		if len(b) == cap(b) {
+			h.Write(b)
+			n += len(b)
+			b = b[:0]
+		}
+	}
+	h.Write(b)
+	n += len(b)
+	var sum [md5.Size]byte
+	copy(sum[:], h.Sum(nil))
+	return n, sum
+}
+
+// VerifyManifest compares the FASTA input r with the manifest written by WriteManifest. Only the manifest is kept in memory. A malformed manifest is an error.
+func VerifyManifest(r io.Reader, manifest io.Reader) (VerifyReport,
+	error) {
+	var rep VerifyReport
+	type entry struct {
+		n   int
+		sum string
+	}
+	entries := make(map[string]entry)
+	var ids []string
+	ms := bufio.NewScanner(manifest)
+	for line := 1; ms.Scan(); line++ {
+		f := strings.Split(ms.Text(), "\t")
+		if len(f) == 1 && f[0] == "" {
+			continue
+		}
+		if len(f) != 3 {
+			return rep, fmt.Errorf("manifest line %d: malformed %q",
+				line, ms.Text())
+		}
+		n, err := strconv.Atoi(f[1])
+		if err != nil {
+			return rep, fmt.Errorf("manifest line %d: %w", line, err)
+		}
+		if _, ok := entries[f[0]]; ok {
+			return rep, fmt.Errorf("manifest line %d: %q: %w", line,
+				f[0], ErrDuplicateID)
+		}
+		entries[f[0]] = entry{n: n, sum: strings.TrimSpace(f[2])}
+		ids = append(ids, f[0])
+	}
+	if err := ms.Err(); err != nil {
+		return rep, err
+	}
+	seen := make(map[string]bool)
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		id := s.ID()
+		e, ok := entries[id]
+		if !ok || seen[id] {
+			rep.Extra = append(rep.Extra, id)
+			continue
+		}
+		seen[id] = true
+		n, sum := upperSum(s, true)
+		if n != e.n || fmt.Sprintf("%x", sum) != strings.ToLower(e.sum) {
+			rep.Mismatched = append(rep.Mismatched, id)
+		} else {
+			rep.Matched++
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return rep, err
+	}
+	for _, id := range ids {
+		if !seen[id] {
+			rep.Missing = append(rep.Missing, id)
+		}
+	}
+	return rep, nil
+}
+
+// VerifyIndex checks that the index fai matches the FASTA input fasta. It returns an error wrapping ErrStaleIndex that lists records missing from either side, and records whose length, offset, or wrapping differ.
+func VerifyIndex(fasta io.Reader, fai io.Reader) error {
+	got, err := BuildFai(fasta)
+	if err != nil {
+		return err
+	}
+	want, err := ReadFai(fai)
+	if err != nil {
+		return err
+	}
+	var probs []string
+	idx := make(map[string]FaiEntry)
+	for _, e := range want {
+		idx[e.Name] = e
+	}
+	for _, g := range got {
+		w, ok := idx[g.Name]
+		if !ok {
+			probs = append(probs, fmt.Sprintf("%q not in index",
+				g.Name))
+			continue
+		}
+		delete(idx, g.Name)
+		if g.Length != w.Length {
+			probs = append(probs, fmt.Sprintf("%q has length %d, index says "+
+				"%d", g.Name, g.Length, w.Length))
+		}
+		if g.Offset != w.Offset {
+			probs = append(probs, fmt.Sprintf("%q starts at offset %d, "+
+				"index says %d", g.Name, g.Offset, w.Offset))
+		}
+		if g.LineBases != w.LineBases || g.LineWidth != w.LineWidth {
+			probs = append(probs, fmt.Sprintf("%q has lines of %d/%d bytes, "+
+				"index says %d/%d", g.Name, g.LineBases, g.LineWidth,
+				w.LineBases, w.LineWidth))
+		}
+	}
+	for _, e := range want {
+		if _, ok := idx[e.Name]; ok {
+			probs = append(probs, fmt.Sprintf("%q not in FASTA", e.Name))
+		}
+	}
+	return staleError(probs)
+}
+
+// staleError returns nil if there are no problems, and otherwise an error wrapping ErrStaleIndex that lists them.
+func staleError(probs []string) error {
+	if len(probs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrStaleIndex, strings.Join(probs, "; "))
+}
+
+// VerifyDict checks that the sequence dictionary dict matches the FASTA input fasta. It returns an error wrapping ErrStaleIndex that lists records missing from either side, and records whose length or MD5 sum differ. The FASTA input is streamed.
+func VerifyDict(fasta io.Reader, dict io.Reader) error {
+	type sqLine struct {
+		ln int
+		m5 string
+	}
+	sq := make(map[string]sqLine)
+	var names []string
+	ds := bufio.NewScanner(dict)
+	for line := 1; ds.Scan(); line++ {
+		f := strings.Split(ds.Text(), "\t")
+		if f[0] != "@SQ" {
+			continue
+		}
+		var l sqLine
+		name, ln := "", ""
+		for _, t := range f[1:] {
+			switch {
+			case strings.HasPrefix(t, "SN:"):
+				name = t[3:]
+			case strings.HasPrefix(t, "LN:"):
+				ln = t[3:]
+			case strings.HasPrefix(t, "M5:"):
+				l.m5 = strings.ToLower(t[3:])
+			}
+		}
+		var err error
+		if l.ln, err = strconv.Atoi(ln); err != nil || name == "" {
+			return fmt.Errorf("dict line %d: malformed @SQ line", line)
+		}
+		sq[name] = l
+		names = append(names, name)
+	}
+	if err := ds.Err(); err != nil {
+		return err
+	}
+	var probs []string
+	sc := NewScanner(fasta)
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		id := s.ID()
+		l, ok := sq[id]
+		if !ok {
+			probs = append(probs, fmt.Sprintf("%q not in dictionary", id))
+			continue
+		}
+		delete(sq, id)
+		if s.Length() != l.ln {
+			probs = append(probs, fmt.Sprintf("%q has length %d, dictionary "+
+				"says %d", id, s.Length(), l.ln))
+		}
+		if l.m5 != "" {
+			_, sum := upperSum(s, false)
+			if m5 := fmt.Sprintf("%x", sum); m5 != l.m5 {
+				probs = append(probs, fmt.Sprintf("%q has MD5 %s, "+
+					"dictionary says %s", id, m5, l.m5))
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	for _, name := range names {
+		if _, ok := sq[name]; ok {
+			probs = append(probs, fmt.Sprintf("%q not in FASTA",
+				name))
+		}
+	}
+	return staleError(probs)
+}
+
+// Rewrap copies the FASTA input r to w with the data wrapped into lines of length lineLength, or into a single line if lineLength is NoWrap. A negative lineLength is an error wrapping ErrLineLength. Headers and the order of records are preserved, blanks in data lines and empty lines are dropped, and line endings become newlines. The input is streamed in pieces no longer than a buffer, so memory stays constant however long its lines are.
+func Rewrap(r io.Reader, w io.Writer, lineLength int) error {
+	if err := checkLineLength(lineLength); err != nil {
+		return err
+	}
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	lineStart, inHeader, seenHeader := true, false, false
+	col := 0
+	for {
+		b, err := br.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull &&
+			err != io.EOF {
+			return err
+		}
+		if lineStart && len(b) > 0 && b[0] == '>' {
+			if col > 0 {
+				bw.WriteByte('\n')
+				col = 0
+			}
+			inHeader, seenHeader = true, true
+		}
+		if inHeader {
+			if n := len(b); n > 0 && b[n-1] == '\n' {
+				bw.Write(bytes.TrimRight(b, "\r\n"))
+				bw.WriteByte('\n')
+				inHeader = false
+			} else {
+				bw.Write(b)
+			}
+		} else {
+			for i := 0; i < len(b); {
+				if c := b[i]; c == '\n' || c == '\r' || c == ' ' || c == '\t' {
+					i++
+					continue
+				}
+				if !seenHeader {
+					return ErrNoHeader
+				}
+				j := i + 1
+				for j < len(b) && b[j] != '\n' && b[j] != '\r' && b[j] != ' ' &&
+					b[j] != '\t' {
+					j++
+				}
+				for i < j {
+					k := j
+					if lineLength > 0 && k-i > lineLength-col {
+						k = i + lineLength - col
+					}
+					bw.Write(b[i:k])
+					col += k - i
+					i = k
+					if col == lineLength {
+						bw.WriteByte('\n')
+						col = 0
+					}
+				}
+			}
+		}
+		lineStart = len(b) > 0 && b[len(b)-1] == '\n'
+		if err == io.EOF {
+			break
+		}
+	}
+	if col > 0 || inHeader {
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// ReverseComplementStream writes the reverse complement of each record in the FASTA input r to w, with suffix, say “ RC”, appended to its header. Each record is wrapped like its input. Only one record is held in memory at a time, in a buffer reused for all records.
+func ReverseComplementStream(r io.Reader, w io.Writer,
+	suffix string) error {
+	sc := NewScanner(r, WithPreserveWrapping())
+	bw := bufio.NewWriter(w)
+	for sc.ScanSequence() {
+		s := Sequence{header: sc.previousHeader + suffix,
+			data: sc.data, lineLength: DefaultLineLength}
+		if sc.lastWrap > 0 {
+			s.lineLength = sc.lastWrap
+		}
+		s.ReverseComplement()
+		if err := format(bw, &s, KeepLineLength, false); err != nil {
+			return err
+		}
+		sc.data = sc.data[:0]
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// ExtractOne copies the first record with ID id from the FASTA input r to w as it is and reports whether it was found. Reading stops at the header that follows the record. Data lines are streamed in pieces, so long lines don't use extra memory.
+func ExtractOne(r io.Reader, id string, w io.Writer) (bool, error) {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	var header []byte
+	lineStart, inHeader, found := true, false, false
+	for {
+		b, err := br.ReadSlice('\n')
+		isHeader := lineStart && len(b) > 0 && b[0] == '>'
+		if found && isHeader {
+			break
+		}
+		if err != nil && err != bufio.ErrBufferFull &&
+			err != io.EOF {
+			return found, err
+		}
+		if isHeader {
+			inHeader = true
+			header = header[:0]
+		}
+		if inHeader {
+			header = append(header, b...)
+			if n := len(b); n == 0 || b[n-1] == '\n' || err == io.EOF {
+				inHeader = false
+				h := strings.TrimSpace(string(header[1:]))
+				if NewSequence(h, nil).ID() == id {
+					found = true
+					bw.Write(header)
+					b = nil
+				}
+			}
+		}
+		if found {
+			bw.Write(b)
+		}
+		lineStart = len(b) > 0 && b[len(b)-1] == '\n'
+		if err == io.EOF {
+			break
+		}
+	}
+	return found, bw.Flush()
+}
+
+// ExtractOneSeq returns the first record with ID id in the FASTA input r, like ExtractOne. A missing record is an error wrapping ErrUnknownSequence.
+func ExtractOneSeq(r io.Reader, id string) (*Sequence, error) {
+	var b bytes.Buffer
+	found, err := ExtractOne(r, id, &b)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%q: %w", id, ErrUnknownSequence)
+	}
+	sc := NewScanner(&b, WithHandoff())
+	sc.ScanSequence()
+	return sc.Sequence(), sc.Err()
+}
+
+// ParseRegion parses a region of the form name, name:start, name:start-, or name:start-end, with one-based, inclusive positions, which may contain commas. It returns the zero-based, half-open interval, where an end of -1 stands for the end of the sequence. The name extends to the last colon that is followed by a valid range, so HLA-DRB1*13:01:01 is parsed as residues 1 to the end of HLA-DRB1*13:01. To look up such names, use Faidx.FetchRegion.
+func ParseRegion(s string) (name string, start, end int, err error) {
+	if i := strings.LastIndexByte(s, ':'); i >= 0 {
+		start, end, err = parseRange(s[i+1:])
+		if err == nil {
+			name = s[:i]
+		}
+	}
+	if name == "" {
+		name, start, end, err = s, 0, -1, nil
+	}
+	if name == "" {
+		err = fmt.Errorf("region %q: no name", s)
+	}
+	return name, start, end, err
+}
+
+// parseRange converts a one-based, inclusive range, start, start-, or start-end, into a zero-based, half-open interval, with -1 for an open end.
+func parseRange(r string) (int, int, error) {
+	r = strings.ReplaceAll(r, ",", "")
+	a, b := r, ""
+	open := true
+	if i := strings.IndexByte(r, '-'); i >= 0 {
+		a, b = r[:i], r[i+1:]
+		open = b == ""
+	}
+	start, err := strconv.Atoi(a)
+	if err != nil || start < 1 {
+		return 0, 0, fmt.Errorf("invalid start %q", a)
+	}
+	end := -1
+	if !open {
+		end, err = strconv.Atoi(b)
+		if err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid end %q", b)
+		}
+	}
+	return start - 1, end, nil
+}
+
+// Lengths returns the ID and length of each record in the FASTA input r, in the order of the input. The residues are counted as they stream by, blanks excluded, and are never stored.
+func Lengths(r io.Reader) ([]SeqLength, error) {
+	var lens []SeqLength
+	br := bufio.NewReader(r)
+	var header []byte
+	lineStart, inHeader := true, false
+	for {
+		b, err := br.ReadSlice('\n')
+		if err != nil && err != bufio.ErrBufferFull &&
+			err != io.EOF {
+			return nil, err
+		}
+		if lineStart && len(b) > 0 && b[0] == '>' {
+			inHeader = true
+			header = header[:0]
+		}
+		if inHeader {
+			header = append(header, b...)
+			if n := len(b); err == io.EOF || n > 0 && b[n-1] == '\n' {
+				inHeader = false
+				h := strings.TrimSpace(string(header[1:]))
+				lens = append(lens, SeqLength{ID: NewSequence(h, nil).ID()})
+			}
+		} else {
+			n := 0
+			for _, c := range b {
+				if c != '\n' && c != '\r' && c != ' ' && c != '\t' {
+					n++
+				}
+			}
+			if n > 0 && len(lens) == 0 {
+				return nil, ErrNoHeader
+			}
+			if n > 0 {
+				lens[len(lens)-1].Length += n
+			}
+		}
+		lineStart = len(b) > 0 && b[len(b)-1] == '\n'
+		if err == io.EOF {
+			break
+		}
+	}
+	return lens, nil
+}
+
+// WriteChromSizes writes the ID and length of each record in the FASTA input r to w in the two-column, tab-separated chrom.sizes format.
+func WriteChromSizes(w io.Writer, r io.Reader) error {
+	lens, err := Lengths(r)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(w)
+	for _, l := range lens {
+		fmt.Fprintf(bw, "%s\t%d\n", l.ID, l.Length)
+	}
+	return bw.Flush()
+}
+
+// CountRecords returns the number of records in the FASTA input r, which is decompressed if gzipped.
+func CountRecords(r io.Reader) (int, error) {
+	br := bufio.NewReaderSize(r, 1<<16)
+	gz, err := isGzip(br)
+	if err != nil {
+		return 0, err
+	}
+	r = br
+	if gz {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return 0, err
+		}
+		defer zr.Close()
+		r = zr
+	}
+	buf := make([]byte, 1<<16)
+	sep := []byte("\n>")
+	n := 0
+	lineStart := true
+	for {
+		k, err := r.Read(buf)
+		if b := buf[:k]; k > 0 {
+			if lineStart && b[0] == '>' {
+				n++
+			}
+			n += bytes.Count(b, sep)
+			lineStart = b[k-1] == '\n'
+		}
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+}
+
+// checkLineLength returns an error if the line length l is negative.
+func checkLineLength(l int) error {
+	if l < 0 {
+		return fmt.Errorf("%w: %d", ErrLineLength, l)
+	}
+	return nil
+}
+
+// WithIrregularities makes the Scanner record the irregularities of its input, which are returned by Irregularities. The parsed output doesn't change. An inconsistent width is reported once per record, at its first offending line, and mixed line endings once per input.
+func WithIrregularities() ScannerOption {
+	return func(s *Scanner) {
+		s.lint = true
+	}
+}
+
+// Lint checks the FASTA input r and reports duplicate IDs, empty records, characters that are neither IUPAC nucleotide or amino acid codes, gaps, nor stops, records in mixed case, data before the first header, content that looks like FASTQ, lines longer than a mebibyte, and a missing final newline. It also reports the irregularities recorded by WithIrregularities. The error returned is that of reading r; problems with the content are findings.
+func Lint(r io.Reader) (LintReport, error) {
+	lr := &lastByteReader{r: r}
+	sc := NewScanner(lr, WithIrregularities())
+	l := &linter{ids: make(map[string]int)}
+	for sc.ScanLine() {
+		l.line(sc.Line(), sc.lineNumber)
+	}
+	if err := sc.Err(); err != nil {
+		return LintReport{}, err
+	}
+	if f := sc.Flush(); len(f) > 0 {
+		l.line(f, sc.lineNumber)
+	}
+	l.endRecord()
+	if lr.n > 0 && lr.last != '\n' {
+		l.add(SeverityWarning, "missing-final-newline", 0, "",
+			"input doesn't end in a newline")
+	}
+	for _, ir := range sc.Irregularities() {
+		sev := SeverityWarning
+		if ir.Kind == TrailingWhitespace {
+			sev = SeverityInfo
+		}
+		check := strings.Replace(ir.Kind.String(), " ", "-", -1)
+		l.add(sev, check, ir.Line, ir.ID, ir.Kind.String())
+	}
+	sort.SliceStable(l.findings, func(i, j int) bool {
+		return l.findings[i].Line < l.findings[j].Line
+	})
+	return LintReport{Records: l.records, Findings: l.findings}, nil
+}
+
+// VerifyAlignment returns an *AlignmentError if seqs isn't an alignment, because it is empty, because its sequences differ in length, or because they mix the gap characters '-' and '.', and nil otherwise. Every sequence of deviating length is listed, and every sequence that uses a gap character other than the first one found. Failing to load a lazy sequence is returned as is.
+func VerifyAlignment(seqs []*Sequence) error {
+	e := &AlignmentError{}
+	if len(seqs) == 0 {
+		e.Problems = append(e.Problems, "no sequences")
+		return e
+	}
+	for _, s := range seqs {
+		if err := s.Materialize(); err != nil {
+			return err
+		}
+	}
+	counts := make(map[int]int)
+	n := seqs[0].Length()
+	for _, s := range seqs {
+		l := s.Length()
+		counts[l]++
+		if counts[l] > counts[n] {
+			n = l
+		}
+	}
+	for _, s := range seqs {
+		if l := s.Length(); l != n {
+			e.Problems = append(e.Problems, fmt.Sprintf("%q has "+
+				"length %d instead of %d", s.ID(), l, n))
+			e.unequal = true
+		}
+	}
+	var gap byte
+	for _, s := range seqs {
+		d := s.Data()
+		for _, g := range []byte("-.") {
+			if bytes.IndexByte(d, g) < 0 {
+				continue
+			}
+			if gap == 0 {
+				gap = g
+			} else if g != gap {
+				e.Problems = append(e.Problems, fmt.Sprintf("%q uses "+
+					"gap %q instead of %q", s.ID(), g, gap))
+			}
+		}
+	}
+	if len(e.Problems) > 0 {
+		return e
+	}
+	return nil
+}
+
+// VerifyPairs reads the headers of the mates in r1 and r2 in parallel and checks that the i-th records of both refer to the same fragment. If one input has more records than the other, its remaining records are counted. Both inputs are streamed record by record. The error returned is that of reading the inputs; mismatches are reported in the PairReport.
+func VerifyPairs(r1, r2 io.Reader) (PairReport, error) {
+	rep := PairReport{FirstMismatch: -1}
+	sc1, sc2 := NewScanner(r1), NewScanner(r2)
+	for {
+		h1, ok1 := nextHeader(sc1)
+		h2, ok2 := nextHeader(sc2)
+		if err := sc1.Err(); err != nil {
+			return rep, fmt.Errorf("input 1: %w", err)
+		}
+		if err := sc2.Err(); err != nil {
+			return rep, fmt.Errorf("input 2: %w", err)
+		}
+		if ok1 {
+			rep.Records1++
+		}
+		if ok2 {
+			rep.Records2++
+		}
+		if !ok1 || !ok2 {
+			break
+		}
+		switch mateStyle(h1, h2) {
+		case styleIdentical:
+			rep.Identical++
+		case styleSlash:
+			rep.Slash++
+		case styleCasava:
+			rep.Casava++
+		default:
+			if rep.Mismatches == 0 {
+				rep.FirstMismatch = rep.Pairs
+				rep.MismatchHeaders = [2]string{h1, h2}
+			}
+			rep.Mismatches++
+		}
+		rep.Pairs++
+	}
+	for _, x := range []struct {
+		sc   *Scanner
+		n    *int
+		name string
+	}{{sc1, &rep.Records1, "input 1"}, {sc2, &rep.Records2, "input 2"}} {
+		for {
+			_, ok := nextHeader(x.sc)
+			if err := x.sc.Err(); err != nil {
+				return rep, fmt.Errorf("%s: %w", x.name, err)
+			}
+			if !ok {
+				break
+			}
+			*x.n++
+		}
+	}
+	return rep, nil
+}
+
+// nextHeader scans the next record of sc and returns its header. The data is discarded, so that scanning headers takes constant memory.
+func nextHeader(sc *Scanner) (string, bool) {
+	if !sc.ScanSequence() {
+		return "", false
+	}
+	sc.data = sc.data[:0]
+	return sc.previousHeader, true
+}
+
+// mateStyle returns the style in which the headers h1 and h2 name the first and second mate of a pair, or styleMismatch if they don't.
+func mateStyle(h1, h2 string) mateNaming {
+	id1, t1 := mateFields(h1)
+	id2, t2 := mateFields(h2)
+	if strings.HasSuffix(id1, "/1") && strings.HasSuffix(id2, "/2") &&
+		id1[:len(id1)-2] == id2[:len(id2)-2] {
+		return styleSlash
+	}
+	if id1 != id2 || strings.HasSuffix(id1, "/1") ||
+		strings.HasSuffix(id1, "/2") {
+		return styleMismatch
+	}
+	c1, c2 := isCasavaMate(t1, '1'), isCasavaMate(t2, '2')
+	switch {
+	case c1 && c2:
+		return styleCasava
+	case c1 || c2 || isCasavaMate(t1, '2') || isCasavaMate(t2, '1'):
+		return styleMismatch
+	}
+	return styleIdentical
+}
+
+// mateFields returns the ID of the header h and the first token of its description.
+func mateFields(h string) (string, string) {
+	f := strings.Fields(h)
+	switch len(f) {
+	case 0:
+		return "", ""
+	case 1:
+		return f[0], ""
+	}
+	return f[0], f[1]
+}
+
+// isCasavaMate reports whether t is a Casava token of mate m, like 1:N:0:ATCACG.
+func isCasavaMate(t string, m byte) bool {
+	return len(t) >= 2 && t[0] == m && t[1] == ':'
 }
@@ -0,0 +1,71 @@
+//go:build go1.18
+// +build go1.18
+
+package fasta
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func addSeeds(f *testing.F) {
+	files, _ := filepath.Glob("data/*.fasta")
+	for _, file := range files {
+		in, err := ioutil.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(in)
+	}
+	seeds := []string{">a\nACGT", ">a\n>b\n>c\n", ">a\nAC\n>",
+		">a\n" + strings.Repeat("ACGT", 1<<16) + "\n",
+		">a\r\nAC\r\nGT\r\n", ">a\x00\nA\x00C\n", "ACGT\n>a\nAC\n",
+		">", "\n\n>a\n\nAC\n\n", "> a \nAC GT \n"}
+	for _, seed := range seeds {
+		f.Add([]byte(seed))
+	}
+}
+func FuzzScanSequence(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, in []byte) {
+		sc := NewScanner(bytes.NewReader(in))
+		n := 0
+		for sc.ScanSequence() {
+			n += sc.Sequence().Length()
+		}
+		if err := sc.Err(); err != nil {
+			t.Fatal(err)
+		}
+		if n > len(in) {
+			t.Errorf("%d residues from %d bytes", n, len(in))
+		}
+	})
+}
+func FuzzRoundTrip(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, in []byte) {
+		want := scanAll(bytes.NewReader(in))
+		for _, s := range want {
+			if bytes.IndexByte(s.Data(), '>') >= 0 {
+				t.Skip("data contains >")
+			}
+		}
+		var b bytes.Buffer
+		for _, s := range want {
+			b.WriteString(s.String() + "\n")
+		}
+		get := scanAll(bytes.NewReader(b.Bytes()))
+		if len(get) != len(want) {
+			t.Fatalf("want %d sequences, get %d", len(want),
+				len(get))
+		}
+		for i := range want {
+			if !get[i].Equals(want[i]) {
+				t.Errorf("want:\n%q\nget:\n%q\n", want[i], get[i])
+			}
+		}
+	})
+}
@@ -2,12 +2,26 @@ package fasta
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 )
 
 func TestEquals(t *testing.T) {
@@ -26,6 +40,14 @@ func TestEquals(t *testing.T) {
 	if s1.Equals(s4) || s4.Equals(s1) {
 		t.Error("sequences with unequal data declared equal")
 	}
+	s2.SetLineLength(3)
+	if !s1.Equals(s2) {
+		t.Error("sequences with unequal line lengths declared unequal")
+	}
+	var n1, n2 *Sequence
+	if !n1.Equals(n2) || n1.Equals(s1) || s1.Equals(n1) {
+		t.Error("wrong equality of nil sequences")
+	}
 }
 func TestString(t *testing.T) {
 	seq := NewSequence("seq", []byte("ACCGT"))
@@ -95,7 +117,28 @@ func TestReverseComplement(t *testing.T) {
 			string(ori.data))
 	}
 }
-
+func TestLength(t *testing.T) {
+	nuc := "ACCGT"
+	seq := NewSequence("", []byte(nuc))
+	l := seq.Length()
+	if l != len(nuc) {
+		t.Errorf("want:\n%d\nget:\n%d\n",
+			len(nuc), l)
+	}
+}
+func TestGC(t *testing.T) {
+	s := []string{"ACCGT", "GGC", "AATAT"}
+	want := []float64{3.0 / 5.0, 3.0 / 3.0, 0.0 / 5.0}
+	get := 1.1
+	for i, r := range s {
+		seq := NewSequence("", []byte(r))
+		get = seq.GC()
+		if get != want[i] {
+			t.Errorf("want:\n%v\nget:\n%v\n",
+				want[i], get)
+		}
+	}
+}
 func TestScanner(t *testing.T) {
 	for i := 1; i <= 9; i++ {
 		name := "./data/seq" + strconv.Itoa(i) + ".fasta"
@@ -152,3 +195,3850 @@ func TestFlush(t *testing.T) {
 	f.Close()
 
 }
+func TestScanN(t *testing.T) {
+	f, _ := os.Open("data/seq8.fasta")
+	defer f.Close()
+	seqs, err := ScanN(f, 2)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(seqs) != 2 {
+		t.Errorf("want:\n%d\nget:\n%d\n", 2, len(seqs))
+	}
+	rest, err := ScanN(f, 10)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(rest) != 3 {
+		t.Errorf("want:\n%d\nget:\n%d\n", 3, len(rest))
+	}
+	f.Seek(0, io.SeekStart)
+	all, _ := ScanN(f, 5)
+	if len(rest) > 0 && !rest[0].Equals(all[2]) {
+		t.Errorf("want:\n%s\nget:\n%s\n", all[2].Header(),
+			rest[0].Header())
+	}
+	f.Seek(0, io.SeekStart)
+	all, _ = ScanN(f, 100)
+	if len(all) != 5 {
+		t.Errorf("want:\n%d\nget:\n%d\n", 5, len(all))
+	}
+}
+func TestWithProgress(t *testing.T) {
+	f, _ := os.Open("data/seq8.fasta")
+	defer f.Close()
+	var bytesRead []int64
+	var records []int
+	fn := func(b int64, r int) {
+		bytesRead = append(bytesRead, b)
+		records = append(records, r)
+	}
+	sc := NewScanner(f, WithProgress(1024, fn))
+	for sc.ScanSequence() {
+		sc.Sequence()
+	}
+	if len(bytesRead) == 0 {
+		t.Fatal("no progress reported")
+	}
+	for i := 1; i < len(bytesRead); i++ {
+		if bytesRead[i] < bytesRead[i-1] {
+			t.Errorf("byte count decreased: %v", bytesRead)
+		}
+	}
+	n := len(bytesRead) - 1
+	if bytesRead[n] != 5165 || records[n] != 5 {
+		t.Errorf("want:\n%d, %d\nget:\n%d, %d\n", 5165, 5,
+			bytesRead[n], records[n])
+	}
+}
+func TestMultiScanner(t *testing.T) {
+	names := []string{"data/seq9.fasta", "data/seq4.fasta"}
+	var want []*Sequence
+	var readers []io.Reader
+	for _, name := range names {
+		f, _ := os.Open(name)
+		want = append(want, scanAll(f)...)
+		f.Seek(0, io.SeekStart)
+		readers = append(readers, f)
+		defer f.Close()
+	}
+	ms := NewMultiScanner(readers...)
+	i := 0
+	for ms.ScanSequence() {
+		seq := ms.Sequence()
+		if i >= len(want) || !seq.Equals(want[i]) {
+			t.Errorf("sequence %d differs", i)
+		} else if (i < 5 && ms.Index() != 0) ||
+			(i >= 5 && ms.Index() != 1) {
+			t.Errorf("wrong index %d for sequence %d",
+				ms.Index(), i)
+		}
+		i++
+	}
+	if i != len(want) {
+		t.Errorf("want:\n%d\nget:\n%d\n", len(want), i)
+	}
+	e := errors.New("broken")
+	ms = NewMultiScanner(strings.NewReader(">s\nACGT\n"),
+		iotest.ErrReader(e))
+	for ms.ScanSequence() {
+	}
+	err := ms.Err()
+	if !errors.Is(err, e) || !strings.HasPrefix(err.Error(), "reader 1") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+func scanAll(r io.Reader) []*Sequence {
+	var seqs []*Sequence
+	sc := NewScanner(r)
+	for sc.ScanSequence() {
+		seqs = append(seqs, sc.Sequence())
+	}
+	return seqs
+}
+func TestPairScanner(t *testing.T) {
+	r1 := ">r1/1\nACGT\n>r2 1:N:0\nGGCC\n"
+	r2 := ">r1/2\nTTTT\n>r2 2:N:0\nAAAA\n"
+	p := NewPairScanner(strings.NewReader(r1), strings.NewReader(r2))
+	n := 0
+	for p.ScanPair() {
+		n++
+	}
+	if p.Err() != nil || n != 2 {
+		t.Errorf("want 2 pairs without error; get %d, %v",
+			n, p.Err())
+	}
+	r3 := ">r1/2\nTTTT\n>r3 2:N:0\nAAAA\n"
+	p = NewPairScanner(strings.NewReader(r1), strings.NewReader(r3))
+	for p.ScanPair() {
+	}
+	if p.Err() == nil || !strings.HasPrefix(p.Err().Error(), "pair 2") {
+		t.Errorf("unexpected error: %v", p.Err())
+	}
+	mates := [][2]string{{"x/1", "x/1"}, {"x/2", "x/1"}, {"x/1", "x"},
+		{"x", "x/2"}}
+	for _, m := range mates {
+		p = NewPairScanner(strings.NewReader(">"+m[0]+"\nA\n"),
+			strings.NewReader(">"+m[1]+"\nC\n"))
+		for p.ScanPair() {
+		}
+		if p.Err() == nil || !strings.HasPrefix(p.Err().Error(), "pair 1") {
+			t.Errorf("%s and %s: unexpected error: %v", m[0], m[1],
+				p.Err())
+		}
+	}
+	r4 := ">r1/2\nTTTT\n"
+	p = NewPairScanner(strings.NewReader(r1), strings.NewReader(r4))
+	for p.ScanPair() {
+	}
+	if p.Err() == nil || !strings.HasPrefix(p.Err().Error(), "pair 2") {
+		t.Errorf("unexpected error: %v", p.Err())
+	}
+}
+func TestInterleave(t *testing.T) {
+	r1 := ">r1/1\nACGT\n>r2/1\nGGCC\n"
+	r2 := ">r1/2\nTTTT\n>r2/2\nAAAA\n"
+	var b, w1, w2 bytes.Buffer
+	err := Interleave(strings.NewReader(r1),
+		strings.NewReader(r2), &b)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err = Deinterleave(&b, &w1, &w2)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if w1.String() != r1 || w2.String() != r2 {
+		t.Errorf("want:\n%s%s\nget:\n%s%s\n", r1, r2,
+			w1.String(), w2.String())
+	}
+	odd := ">r1/1\nA\n>r1/2\nC\n>r3/1\nA\n"
+	err = Deinterleave(strings.NewReader(odd), &w1, &w2)
+	if err == nil || !strings.Contains(err.Error(), "no mate") {
+		t.Errorf("odd number of sequences not detected: %v", err)
+	}
+}
+func TestWhitespace(t *testing.T) {
+	f, _ := os.Open("data/seq10.fasta")
+	defer f.Close()
+	get := scanAll(f)
+	want := scanAll(strings.NewReader(">chr1\nACGTAC\n>chr2 x\nGG"))
+	if len(get) != len(want) {
+		t.Fatalf("want:\n%d\nget:\n%d\n", len(want), len(get))
+	}
+	for i, seq := range get {
+		if !seq.Equals(want[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], seq)
+		}
+	}
+	f.Seek(0, io.SeekStart)
+	sc := NewScanner(f, WithStrictHeaders())
+	for sc.ScanSequence() {
+	}
+	if sc.Err() == nil {
+		t.Error("untrimmed header not detected")
+	}
+}
+func TestFastqScanner(t *testing.T) {
+	f, _ := os.Open("data/reads.fastq")
+	defer f.Close()
+	sc := NewFastqScanner(f)
+	want := []string{"r1", "r2 desc", "r3"}
+	wantQ := []string{"IIII", "@III#!!!AB", "+I#"}
+	i := 0
+	for sc.ScanRecord() {
+		r := sc.Record()
+		if i < len(want) {
+			if r.Header() != want[i] || string(r.Quality()) != wantQ[i] {
+				t.Errorf("want:\n%s %s\nget:\n%s %s\n", want[i],
+					wantQ[i], r.Header(), r.Quality())
+			}
+			if r.Length() != len(r.Quality()) {
+				t.Errorf("record %d: length %d, quality %d", i,
+					r.Length(), len(r.Quality()))
+			}
+		}
+		i++
+	}
+	if sc.Err() != nil || i != len(want) {
+		t.Errorf("want %d records; get %d, %v", len(want), i,
+			sc.Err())
+	}
+	bad := []string{"@r1\nACGT\n+\nIIIII\n", "@r1\nACGT\n+\nII"}
+	for _, b := range bad {
+		sc = NewFastqScanner(strings.NewReader(b))
+		for sc.ScanRecord() {
+		}
+		if sc.Err() == nil || !strings.Contains(sc.Err().Error(), "r1") {
+			t.Errorf("unexpected error: %v", sc.Err())
+		}
+	}
+}
+func TestFastqToFasta(t *testing.T) {
+	f, _ := os.Open("data/reads.fastq")
+	defer f.Close()
+	var b bytes.Buffer
+	if err := FastqToFasta(f, &b); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	want := ">r1\nACGT\n>r2 desc\nACGTACGTAA\n>r3\nGGC\n"
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+}
+func TestMeanQuality(t *testing.T) {
+	q, _ := NewQualSequence("q", []byte("AC"), []byte("I5"))
+	if m := q.MeanQuality(); m != 30 {
+		t.Errorf("want:\n%v\nget:\n%v\n", 30.0, m)
+	}
+	q, _ = NewQualSequence("q", nil, nil)
+	if m := q.MeanQuality(); m != 0 {
+		t.Errorf("want:\n%v\nget:\n%v\n", 0.0, m)
+	}
+	_, err := NewQualSequence("q", []byte("AC"), []byte("I"))
+	if err == nil {
+		t.Error("unequal lengths not detected")
+	}
+}
+func TestTrimQuality(t *testing.T) {
+	tests := []struct {
+		q, want string
+	}{
+		{"##IIII#", "IIII"},
+		{"II#II#", "II#II"},
+		{"####", ""},
+		{"IIII", "IIII"},
+	}
+	for _, test := range tests {
+		d := bytes.Repeat([]byte("A"), len(test.q))
+		q, _ := NewQualSequence("q", d, []byte(test.q))
+		q.TrimQuality(20)
+		if string(q.Quality()) != test.want ||
+			q.Length() != len(test.want) {
+			t.Errorf("want:\n%s\nget:\n%s\n", test.want,
+				q.Quality())
+		}
+	}
+}
+func TestQualityEncoding(t *testing.T) {
+	quals := []string{"II#5", "hhB@", "II\x1f"}
+	want := []int{33, 64, 0}
+	for i, qual := range quals {
+		d := bytes.Repeat([]byte("A"), len(qual))
+		q, _ := NewQualSequence("q", d, []byte(qual))
+		o, err := q.QualityEncoding()
+		if o != want[i] || (want[i] == 0) != (err != nil) {
+			t.Errorf("want:\n%d\nget:\n%d, %v\n", want[i],
+				o, err)
+		}
+	}
+}
+func TestQualConversion(t *testing.T) {
+	s := NewSequence("s", []byte("ACGT"))
+	q := s.ToQualSequence('I')
+	if string(q.Quality()) != "IIII" {
+		t.Errorf("want:\n%s\nget:\n%s\n", "IIII", q.Quality())
+	}
+	if !q.ToSequence().Equals(s) {
+		t.Error("round trip changed sequence")
+	}
+}
+func TestQual(t *testing.T) {
+	f, _ := os.Open("data/reads.qual")
+	defer f.Close()
+	quals, err := ReadQual(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	seqs := []*Sequence{NewSequence("r1", []byte("ACGT")),
+		NewSequence("r2 desc", []byte("ACGTA"))}
+	recs, err := AttachQual(seqs, quals)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var b bytes.Buffer
+	WriteQual(&b, recs, 3)
+	want := ">r1\n40 40 30\n20\n>r2 desc\n10 20 30\n40 0\n"
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+	bad := [][]*Sequence{
+		{NewSequence("r1", []byte("ACG")), seqs[1]},
+		{seqs[0]},
+		{seqs[0], seqs[1], NewSequence("r3", []byte("A"))},
+	}
+	names := []string{"r1", "r2 desc", "r3"}
+	for i, b := range bad {
+		_, err = AttachQual(b, quals)
+		if err == nil || !strings.Contains(err.Error(), names[i]) {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+}
+func TestWriteFastq(t *testing.T) {
+	long := bytes.Repeat([]byte("A"), 100)
+	q1, _ := NewQualSequence("r1", []byte("ACGT"), []byte("@+I#"))
+	q2 := SequenceToFastq(NewSequence("r2 x", long), '+')
+	recs := []*QualSequence{q1, q2}
+	var b bytes.Buffer
+	if err := WriteFastq(&b, recs); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := strings.Count(b.String(), "\n"); n != 8 {
+		t.Errorf("want:\n%d lines\nget:\n%d lines\n", 8, n)
+	}
+	sc := NewFastqScanner(&b)
+	i := 0
+	for sc.ScanRecord() {
+		r := sc.Record()
+		if i < len(recs) && (r.Header() != recs[i].Header() ||
+			!bytes.Equal(r.Data(), recs[i].Data()) ||
+			!bytes.Equal(r.Quality(), recs[i].Quality())) {
+			t.Errorf("record %d changed in round trip", i)
+		}
+		i++
+	}
+	if sc.Err() != nil || i != len(recs) {
+		t.Errorf("want %d records; get %d, %v", len(recs), i, sc.Err())
+	}
+}
+func TestReadGenBank(t *testing.T) {
+	f, _ := os.Open("data/seq1.gb")
+	defer f.Close()
+	seqs, err := ReadGenBank(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []*Sequence{
+		NewSequence("AB000001 Example sequence spanning two lines.",
+			[]byte("agctttcattctgactgcaacgggcaatatgtctctgtgt"+
+				"ggattaaaaaaagagtgtct")),
+		NewSequence("AB000002 Second example.",
+			[]byte("ggcatgcaat")),
+	}
+	if len(seqs) != len(want) {
+		t.Fatalf("want:\n%d\nget:\n%d\n", len(want), len(seqs))
+	}
+	for i, seq := range seqs {
+		if !seq.Equals(want[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], seq)
+		}
+	}
+	g, _ := os.Open("data/seq2.gb")
+	defer g.Close()
+	seqs, err = ReadGenBank(g)
+	if len(seqs) != 1 || err == nil ||
+		!strings.Contains(err.Error(), "skipped 1") {
+		t.Errorf("want 1 sequence and 1 skipped; get %d, %v",
+			len(seqs), err)
+	}
+}
+func TestReadEMBL(t *testing.T) {
+	f, _ := os.Open("data/seq1.embl")
+	defer f.Close()
+	seqs, err := ReadEMBL(f)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	g, _ := os.Open("data/seq1.gb")
+	defer g.Close()
+	want, _ := ReadGenBank(g)
+	if len(seqs) != len(want) {
+		t.Fatalf("want:\n%d\nget:\n%d\n", len(want), len(seqs))
+	}
+	for i, seq := range seqs {
+		if !seq.Equals(want[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], seq)
+		}
+	}
+	_, err = ReadEMBL(strings.NewReader("ID   X1;\nSQ\n acgt\n"))
+	if err == nil {
+		t.Error("unterminated record not detected")
+	}
+}
+func TestTab(t *testing.T) {
+	seqs := []*Sequence{NewSequence("s1 desc", []byte("ACGT")),
+		NewSequence("s2", []byte("GG"))}
+	length := func(s *Sequence) string {
+		return strconv.Itoa(s.Length())
+	}
+	var b bytes.Buffer
+	WriteTab(&b, seqs, length)
+	want := "s1 desc\tACGT\t4\ns2\tGG\t2\n"
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+	get, err := ReadTab(&b)
+	if err != nil || len(get) != 2 ||
+		!get[0].Equals(seqs[0]) || !get[1].Equals(seqs[1]) {
+		t.Errorf("round trip failed: %v", err)
+	}
+	err = WriteTab(&b, []*Sequence{NewSequence("a\tb", nil)})
+	if err == nil {
+		t.Error("tab in header not detected")
+	}
+	get, err = ReadTab(strings.NewReader("s1\tA\nbroken\n\ns2\tC"))
+	if len(get) != 2 || err == nil ||
+		!strings.HasSuffix(err.Error(), ": 2") {
+		t.Errorf("want 2 sequences and line 2 reported; get %d, %v",
+			len(get), err)
+	}
+}
+func TestFilter(t *testing.T) {
+	seqs := []*Sequence{NewSequence("s1", []byte("ACGT")),
+		NewSequence("s2 plasmid", []byte("GGGCCA")),
+		NewSequence("s3", []byte("AT"))}
+	preds := []func(*Sequence) bool{MinLength(4), MaxLength(4),
+		GCBetween(0.5, 1), HeaderContains("plasmid")}
+	want := []string{"s1 s2 plasmid", "s1 s3", "s1 s2 plasmid",
+		"s2 plasmid"}
+	for i, pred := range preds {
+		var h []string
+		for _, s := range Filter(seqs, pred) {
+			h = append(h, s.Header())
+		}
+		if get := strings.Join(h, " "); get != want[i] {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], get)
+		}
+	}
+}
+func TestFilterStream(t *testing.T) {
+	f, _ := os.Open("data/seq8.fasta")
+	defer f.Close()
+	var b bytes.Buffer
+	first := scanAll(f)[0]
+	f.Seek(0, io.SeekStart)
+	keep := func(s *Sequence) bool {
+		return s.Header() == first.Header()
+	}
+	kept, dropped, err := FilterStream(f, &b, keep)
+	if err != nil || kept != 1 || dropped != 4 {
+		t.Errorf("want:\n1 4 <nil>\nget:\n%d %d %v\n", kept,
+			dropped, err)
+	}
+	if b.String() != first.String()+"\n" {
+		t.Errorf("want:\n%s\nget:\n%s\n", first, b.String())
+	}
+}
+func TestSortByLength(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a", []byte("AC")),
+		NewSequence("b", []byte("ACGT")),
+		NewSequence("c", []byte("GT")),
+		NewSequence("d", []byte("A"))}
+	SortByLength(seqs, false)
+	checkOrder(t, seqs, "d a c b")
+	SortByLength(seqs, true)
+	checkOrder(t, seqs, "b a c d")
+	SortByLength(seqs, true)
+	checkOrder(t, seqs, "b a c d")
+}
+func checkOrder(t *testing.T, seqs []*Sequence, want string) {
+	var h []string
+	for _, s := range seqs {
+		h = append(h, s.Header())
+	}
+	if get := strings.Join(h, " "); get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+}
+func TestSortByHeader(t *testing.T) {
+	var seqs []*Sequence
+	for _, h := range strings.Fields("chr10 chr2 chrX chr1 " +
+		"chr2_random chr1a2 chr1a10 chr01 scaffold9") {
+		seqs = append(seqs, NewSequence(h, nil))
+	}
+	SortByHeader(seqs)
+	checkOrder(t, seqs, "chr01 chr1 chr1a2 chr1a10 chr2 "+
+		"chr2_random chr10 chrX scaffold9")
+}
+func TestSortByKeys(t *testing.T) {
+	var seqs []*Sequence
+	for _, x := range strings.Fields("s10:AC s2:GGGC s1:AC s3:AT " +
+		"s20:GG") {
+		f := strings.Split(x, ":")
+		seqs = append(seqs, NewSequence(f[0], []byte(f[1])))
+	}
+	SortByKeys(seqs, ByLengthDesc, ByIDNatural)
+	checkOrder(t, seqs, "s2 s1 s3 s10 s20")
+	SortByKeys(seqs, ByLengthAsc)
+	checkOrder(t, seqs, "s1 s3 s10 s20 s2")
+	byLastResidue := func(a, b *Sequence) int {
+		return int(a.data[len(a.data)-1]) - int(b.data[len(b.data)-1])
+	}
+	SortByKeys(seqs, ByGC, byLastResidue)
+	checkOrder(t, seqs, "s3 s1 s10 s2 s20")
+	SortByKeys(seqs)
+	checkOrder(t, seqs, "s3 s1 s10 s2 s20")
+	tests := []struct {
+		a, b string
+		sign int
+	}{
+		{"chr2", "chr10", -1},
+		{"chr10", "chr2", 1},
+		{"chr01", "chr1", -1},
+		{"chr1", "chr1", 0},
+		{"chr1a", "chr1", 1},
+	}
+	for _, test := range tests {
+		c := CompareNatural(test.a, test.b)
+		if (c < 0 && test.sign >= 0) || (c > 0 && test.sign <= 0) ||
+			(c == 0 && test.sign != 0) {
+			t.Errorf("CompareNatural(%q, %q) = %d, want sign %d",
+				test.a, test.b, c, test.sign)
+		}
+	}
+}
+func TestIDDescription(t *testing.T) {
+	headers := []string{"chr1 Homo sapiens", "chr2", " chr3\tx  y "}
+	ids := []string{"chr1", "chr2", "chr3"}
+	descs := []string{"Homo sapiens", "", "x  y"}
+	for i, h := range headers {
+		s := NewSequence(h, nil)
+		if s.ID() != ids[i] || s.Description() != descs[i] {
+			t.Errorf("want:\n%q %q\nget:\n%q %q\n", ids[i],
+				descs[i], s.ID(), s.Description())
+		}
+	}
+}
+func TestDeduplicateByData(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a", []byte("ACGT")),
+		NewSequence("b", []byte("GG")),
+		NewSequence("c", []byte("ACGT")),
+		NewSequence("d", []byte("acgt"))}
+	u, d := DeduplicateByData(seqs)
+	checkOrder(t, u, "a b d")
+	if len(d) != 1 || strings.Join(d["a"], " ") != "c" {
+		t.Errorf("unexpected duplicates: %v", d)
+	}
+	u, d = DeduplicateByDataFold(seqs)
+	checkOrder(t, u, "a b")
+	if len(d) != 1 || strings.Join(d["a"], " ") != "c d" {
+		t.Errorf("unexpected duplicates: %v", d)
+	}
+}
+func TestDeduplicateByID(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a x", []byte("A")),
+		NewSequence("b", []byte("C")),
+		NewSequence("a y", []byte("G"))}
+	u, d := DeduplicateByID(seqs)
+	checkOrder(t, u, "a x b")
+	if len(d) != 1 || strings.Join(d["a x"], " ") != "a y" {
+		t.Errorf("unexpected duplicates: %v", d)
+	}
+}
+func TestDeduplicateCanonical(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a", []byte("AACGTG")),
+		NewSequence("b", []byte("CACGTT")),
+		NewSequence("c", []byte("AACGTG")),
+		NewSequence("d", []byte("AACGTC"))}
+	u, d := DeduplicateCanonical(seqs)
+	checkOrder(t, u, "a d")
+	want := "b" + ReverseComplementMark + ",c"
+	if get := strings.Join(d["a"], ","); len(d) != 1 || get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+	if !bytes.Equal(seqs[1].Data(), []byte("CACGTT")) {
+		t.Error("input mutated")
+	}
+}
+func TestStats(t *testing.T) {
+	var seqs []*Sequence
+	for _, d := range []string{"GC", "ATN", "acgt", "NNNNN",
+		"GGGCCC"} {
+		seqs = append(seqs, NewSequence("s", []byte(d)))
+	}
+	want := AssemblyStats{Count: 5, TotalLength: 20, MinLength: 2,
+		MaxLength: 6, MeanLength: 4, MedianLength: 4, N50: 5,
+		N90: 3, L50: 2, GC: 10.0 / 14.0, NCount: 6,
+		LowercaseCount: 4}
+	if get := Stats(seqs); get != want {
+		t.Errorf("want:\n%+v\nget:\n%+v\n", want, get)
+	}
+	f, _ := os.Open("data/seq6.fasta")
+	defer f.Close()
+	want = Stats(scanAll(f))
+	f.Seek(0, io.SeekStart)
+	get, err := StatsStream(f)
+	if err != nil || get != want || get.Count != 2 {
+		t.Errorf("want:\n%+v\nget:\n%+v\n", want, get)
+	}
+}
+func TestWriteStatsTable(t *testing.T) {
+	seqs := []*Sequence{NewSequence("s1 desc", []byte("ACgtN")),
+		NewSequence("s2", nil)}
+	want := StatsTableHeader + "\n" +
+		"s1\t5\t0.5000\t1\t0.4000\t" +
+		"252fe4e1c9aa67ce660443056dfa3799\n" +
+		"s2\t0\t0.0000\t0\t0.0000\t" +
+		"d41d8cd98f00b204e9800998ecf8427e\n"
+	var b bytes.Buffer
+	WriteStatsTable(&b, seqs)
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+	b.Reset()
+	r := strings.NewReader(">s1 desc\nACgtN\n>s2\n")
+	WriteStatsTableStream(&b, r)
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+}
+func TestConcatenate(t *testing.T) {
+	d := make([]byte, 2, 10)
+	copy(d, "AC")
+	s1 := NewSequence("s1", nil)
+	s1.SetData(d)
+	s2 := NewSequence("s2", []byte("GT"))
+	c, err := Concatenate([]*Sequence{s1, s2}, "$$")
+	want := NewSequence("s1$$s2", []byte("AC$$GT"))
+	if err != nil || !c.Equals(want) {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, c)
+	}
+	c.Data()[0] = 'N'
+	c.SetData(append(c.Data(), 'N'))
+	if string(s1.Data()) != "AC" || string(d[:3]) != "AC\x00" ||
+		string(s2.Data()) != "GT" {
+		t.Error("input changed")
+	}
+	if _, err = Concatenate(nil, "$"); err == nil {
+		t.Error("empty input not detected")
+	}
+}
+func TestDeconcatenate(t *testing.T) {
+	seqs := []*Sequence{NewSequence("s1", []byte("AC")),
+		NewSequence("s2", nil), NewSequence("s3", []byte("T"))}
+	c, _ := Concatenate(seqs, "$")
+	get, err := Deconcatenate(c, "$")
+	if err != nil || len(get) != len(seqs) {
+		t.Fatalf("want:\n%d\nget:\n%d, %v\n", len(seqs), len(get),
+			err)
+	}
+	for i, s := range get {
+		if !s.Equals(seqs[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", seqs[i], s)
+		}
+	}
+	_, err = Deconcatenate(NewSequence("a$b", []byte("ACGT")), "$")
+	if err == nil {
+		t.Error("unequal segment numbers not detected")
+	}
+}
+func TestConcatenateMapped(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a", []byte("ACG")),
+		NewSequence("b", []byte("T")),
+		NewSequence("c", []byte("GG"))}
+	c, m, err := ConcatenateMapped(seqs, "$$")
+	if err != nil || string(c.Data()) != "ACG$$T$$GG" {
+		t.Fatalf("unexpected concatenation: %s, %v", c, err)
+	}
+	var b bytes.Buffer
+	m.Write(&b)
+	m2, err := ReadCoordMap(&b)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, cm := range []*CoordMap{m, m2} {
+		pos := []int{0, 2, 3, 5, 6, 8, 9, 10, -1}
+		names := []string{"a", "a", "", "b", "", "c", "c", "", ""}
+		offsets := []int{0, 2, 0, 0, 0, 0, 1, 0, 0}
+		for i, p := range pos {
+			name, off, err := cm.Locate(p)
+			if name != names[i] || off != offsets[i] ||
+				(names[i] == "") != (err != nil) {
+				t.Errorf("%d: want:\n%q %d\nget:\n%q %d %v\n", p,
+					names[i], offsets[i], name, off, err)
+			}
+		}
+	}
+}
+func TestSample(t *testing.T) {
+	f, _ := os.Open("data/seq8.fasta")
+	defer f.Close()
+	seqs := scanAll(f)
+	s1 := Sample(seqs, 2, rand.New(rand.NewSource(3)))
+	s2 := Sample(seqs, 2, rand.New(rand.NewSource(3)))
+	if len(s1) != 2 || !s1[0].Equals(s2[0]) || !s1[1].Equals(s2[1]) {
+		t.Error("sampling not reproducible")
+	}
+	idx := make(map[string]int)
+	for i, s := range seqs {
+		idx[s.Header()] = i
+	}
+	if idx[s1[0].Header()] >= idx[s1[1].Header()] {
+		t.Error("sample not in input order")
+	}
+	if len(Sample(seqs, 10, rand.New(rand.NewSource(3)))) != 5 {
+		t.Error("oversized sample doesn't contain all sequences")
+	}
+	f.Seek(0, io.SeekStart)
+	s3, err := SampleStream(f, 2, rand.New(rand.NewSource(3)))
+	if err != nil || len(s3) != 2 || !s3[0].Equals(s1[0]) ||
+		!s3[1].Equals(s1[1]) {
+		t.Error("streaming sample differs")
+	}
+}
+func TestSampleUniform(t *testing.T) {
+	var seqs []*Sequence
+	for i := 0; i < 5; i++ {
+		seqs = append(seqs, NewSequence(strconv.Itoa(i), nil))
+	}
+	r := rand.New(rand.NewSource(1))
+	counts := make(map[string]int)
+	n := 10000
+	for i := 0; i < n; i++ {
+		counts[Sample(seqs, 1, r)[0].Header()]++
+	}
+	for h, c := range counts {
+		if c < n/5-n/25 || c > n/5+n/25 {
+			t.Errorf("sequence %s drawn %d times", h, c)
+		}
+	}
+}
+func TestSampleFraction(t *testing.T) {
+	f, _ := os.Open("data/seq8.fasta")
+	defer f.Close()
+	r := rand.New(rand.NewSource(1))
+	for _, p := range []float64{0, 1} {
+		f.Seek(0, io.SeekStart)
+		var b bytes.Buffer
+		n, err := SampleFraction(f, &b, p, r)
+		if err != nil || n != int(p*5) {
+			t.Errorf("want:\n%d\nget:\n%d\n", int(p*5), n)
+		}
+	}
+}
+func TestDiff(t *testing.T) {
+	a := ">s1\nACGTAC\n>s2\nAACG\n>s3\nAACG\n>s4\nAC\n>s5\nA\n"
+	b := ">s0\nC\n>s1 new\nACG\nTAC\n>s2\naaCG\n>s3\nCGTT\n>s4\nACG\n"
+	rep, err := Diff(strings.NewReader(a), strings.NewReader(b))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := DiffReport{OnlyA: []string{"s5"}, OnlyB: []string{"s0"},
+		Identical: []string{"s1"},
+		Different: []RecordDiff{
+			{ID: "s2", LengthA: 4, LengthB: 4, CaseOnly: true},
+			{ID: "s3", LengthA: 4, LengthB: 4, StrandOnly: true},
+			{ID: "s4", LengthA: 2, LengthB: 3}}}
+	if !reflect.DeepEqual(rep, want) || rep.Equal() {
+		t.Errorf("want:\n%+v\nget:\n%+v\n", want, rep)
+	}
+	rep, _ = Diff(strings.NewReader(a), strings.NewReader(a))
+	if !rep.Equal() {
+		t.Error("identical inputs reported as different")
+	}
+}
+func TestMerge(t *testing.T) {
+	a := ">s1 a\nA\n>s2\nC\n"
+	b := ">s1 b\nG\n>s2\nC\n>s3\nT\n"
+	policies := []MergePolicy{MergeError, KeepFirst, KeepLast,
+		RenameWithSuffix, KeepIfIdentical}
+	want := []string{"",
+		">s1 a\nA\n>s2\nC\n>s3\nT\n",
+		">s1 b\nG\n>s2\nC\n>s3\nT\n",
+		">s1 a\nA\n>s2\nC\n>s1_2 b\nG\n>s2_2\nC\n>s3\nT\n",
+		""}
+	for i, p := range policies {
+		var out bytes.Buffer
+		rep, err := Merge(&out, p, strings.NewReader(a), strings.NewReader(b))
+		if want[i] == "" {
+			if err == nil {
+				t.Errorf("policy %d: collision not detected", p)
+			}
+			continue
+		}
+		if err != nil || out.String() != want[i] {
+			t.Errorf("policy %d: want:\n%s\nget:\n%s%v\n", p, want[i],
+				out.String(), err)
+		}
+		if rep.Collisions != 2 {
+			t.Errorf("policy %d: want 2 collisions, get %d", p,
+				rep.Collisions)
+		}
+	}
+}
+func TestMergeReport(t *testing.T) {
+	a := ">s1 a\nA\n>s2\nC\n"
+	b := ">s1 b\nG\n>s3\nT\n"
+	rep, _ := Merge(ioutil.Discard, KeepLast, strings.NewReader(a),
+		strings.NewReader(b))
+	want := MergeReport{Written: []int{1, 2}, Dropped: []int{1, 0},
+		Collisions: 1}
+	if !reflect.DeepEqual(rep, want) {
+		t.Errorf("want:\n%+v\nget:\n%+v\n", want, rep)
+	}
+}
+func TestReorder(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a x", nil),
+		NewSequence("b", nil), NewSequence("c", nil)}
+	res, err := Reorder(seqs, []string{"c", "a", "b"}, true)
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	checkOrder(t, res, "c a x b")
+	res, _ = Reorder(seqs, []string{"c", "z"}, false)
+	checkOrder(t, res, "c a x b")
+	if _, err = Reorder(seqs, []string{"c", "b"}, true); err == nil {
+		t.Error("unnamed sequence not detected")
+	}
+	_, err = Reorder(seqs, []string{"c", "a", "b", "z"}, true)
+	if err == nil {
+		t.Error("unknown name not detected")
+	}
+	res, _ = ReorderByHeader(seqs, []string{"a x", "a"}, false)
+	checkOrder(t, res, "a x b c")
+}
+func TestPartition(t *testing.T) {
+	seqs := []*Sequence{NewSequence("a", []byte("A")),
+		NewSequence("b", []byte("GC")),
+		NewSequence("c", []byte("GCA")),
+		NewSequence("d", []byte("GAAA"))}
+	bins := PartitionByLength(seqs, []int{2, 4})
+	want := []string{"a", "b c", "d"}
+	for i, b := range bins {
+		checkOrder(t, b, want[i])
+	}
+	bins = PartitionByGC(seqs, []float64{0.25, 0.5})
+	want = []string{"a", "d", "b c"}
+	for i, b := range bins {
+		checkOrder(t, b, want[i])
+	}
+	var b1, b2 bytes.Buffer
+	in := ">a\nA\n>b\nGC\n"
+	err := PartitionStream(strings.NewReader(in), []io.Writer{&b1, &b2},
+		LengthBin([]int{2}))
+	if err != nil || b1.String() != ">a\nA\n" || b2.String() != ">b\nGC\n" {
+		t.Errorf("unexpected partition: %q %q %v", b1.String(),
+			b2.String(), err)
+	}
+}
+func TestWindows(t *testing.T) {
+	seqs := []*Sequence{NewSequence("s1 desc", []byte("ACGTACGTAC")),
+		NewSequence("s2", []byte("NNNNA"))}
+	wins := Windows(seqs, 4, 3)
+	checkOrder(t, wins, "s1:0-4 desc s1:3-7 desc s1:6-10 desc "+
+		"s2:0-4 s2:3-5")
+	if string(wins[2].Data()) != "GTAC" {
+		t.Errorf("want:\nGTAC\nget:\n%s\n", wins[2].Data())
+	}
+	wins = Filter(wins, MaxNFraction(0.5))
+	checkOrder(t, wins, "s1:0-4 desc s1:3-7 desc s1:6-10 desc "+
+		"s2:3-5")
+}
+func TestExtractBED(t *testing.T) {
+	seqs := []*Sequence{NewSequence("chr1 x", []byte("AACCGGTT")),
+		NewSequence("chr2", []byte("ACGT"))}
+	bed := "track name=test\nchr1\t0\t3\tfirst\t0\t+\n" +
+		"chr1\t4\t8\t.\t0\t-\nchr2\t2\t6\n"
+	_, err := ExtractBED(seqs, strings.NewReader(bed))
+	if err == nil || !strings.Contains(err.Error(), "bed line 4") {
+		t.Errorf("want error on bed line 4, get %v", err)
+	}
+	out, err := ExtractBEDClamped(seqs, strings.NewReader(bed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := ""
+	for _, s := range out {
+		get += s.String() + "\n"
+	}
+	want := ">first x\nAAC\n>chr1:4-8 x\nAACC\n>chr2:2-6\nGT\n"
+	if get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+	_, err = ExtractBED(seqs, strings.NewReader("chr3\t0\t1\n"))
+	if err == nil || !strings.Contains(err.Error(), "bed line 1") {
+		t.Errorf("want error on bed line 1, get %v", err)
+	}
+}
+func TestMaskBED(t *testing.T) {
+	s := NewSequence("chr1", []byte("ACGTACGT"))
+	seqs := []*Sequence{s}
+	bed := "chr1\t0\t3\nchr1\t2\t4\n"
+	n, err := MaskBED(seqs, strings.NewReader(bed), true)
+	if err != nil || n != 4 || string(s.Data()) != "acgtACGT" {
+		t.Errorf("soft: %d %s %v", n, s.Data(), err)
+	}
+	bed = "chr1\t6\t8\n1\t0\t1\n"
+	n, err = MaskBED(seqs, strings.NewReader(bed), false)
+	if err == nil || err.Error() != "unknown sequences in bed: 1" ||
+		n != 2 || string(s.Data()) != "acgtACNN" {
+		t.Errorf("hard: %d %s %v", n, s.Data(), err)
+	}
+}
+func TestRenameFromMap(t *testing.T) {
+	seqs := []*Sequence{NewSequence("chr1 first", nil),
+		NewSequence("chr2", nil), NewSequence("chrM", nil)}
+	m := "chr1\t1\nchr2\t2\nchrX\tX\n"
+	_, err := RenameFromMap(seqs, strings.NewReader(m), true)
+	if err == nil || seqs[0].Header() != "chr1 first" {
+		t.Errorf("want strict error, get %v", err)
+	}
+	n, err := RenameFromMap(seqs, strings.NewReader(m), false)
+	if err != nil || n != 2 {
+		t.Errorf("want 2 renamed, get %d, %v", n, err)
+	}
+	if seqs[0].Header() != "1 first" || seqs[1].Header() != "2" {
+		t.Errorf("unexpected headers: %q %q", seqs[0].Header(),
+			seqs[1].Header())
+	}
+	m = "1\tchrM\n"
+	_, err = RenameFromMap(seqs, strings.NewReader(m), false)
+	if err == nil || seqs[0].ID() != "1" {
+		t.Errorf("want duplicate error, get %v", err)
+	}
+}
+func TestKmerSpectrum(t *testing.T) {
+	in := ">s1\nACGT\n>s2\nNac\n"
+	counts, total, distinct, err := KmerSpectrum(
+		strings.NewReader(in), 2)
+	if err != nil || total != 4 || distinct != 2 {
+		t.Errorf("want 4 total, 2 distinct, get %d, %d, %v",
+			total, distinct, err)
+	}
+	if counts[1] != 3 || counts[6] != 1 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+	h := KmerHistogram(counts)
+	want := []int{0, 1, 0, 1}
+	if !reflect.DeepEqual(h, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, h)
+	}
+	_, _, _, err = KmerSpectrum(strings.NewReader(in), 33)
+	if err == nil {
+		t.Error("want error for k = 33")
+	}
+	counts, _, _, _ = KmerSpectrum(strings.NewReader(
+		">s\nTTTTTTTTTTTTTTTTTTTTTTTTTTTTTTTT\n"), 32)
+	if counts[0] != 1 {
+		t.Errorf("want poly-A 32-mer, get %v", counts)
+	}
+}
+func TestFindContained(t *testing.T) {
+	subjects := []*Sequence{
+		NewSequence("c1", []byte("AAAACCCCGGGGTTTTACGA")),
+		NewSequence("c2", []byte("ttggggttttcc"))}
+	queries := []*Sequence{
+		NewSequence("fwd", []byte("AAAACCCC")),
+		NewSequence("rev", []byte("TCGTAAAA")),
+		NewSequence("absent", []byte("ACACACAC")),
+		NewSequence("short", []byte("TTG"))}
+	get := FindContained(queries, subjects, 5)
+	want := map[string][]string{
+		"fwd":   {"c1", "c2"},
+		"rev":   {"c1"},
+		"short": {"c2"}}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+}
+func syntheticFasta(n, l int) []byte {
+	r := rand.New(rand.NewSource(1))
+	var b bytes.Buffer
+	d := make([]byte, l)
+	for i := 0; i < n; i++ {
+		for j := range d {
+			d[j] = "ACGT"[r.Intn(4)]
+		}
+		s := NewSequence(fmt.Sprintf("s%d", i+1), d)
+		fmt.Fprintf(&b, "%s\n", s)
+	}
+	return b.Bytes()
+}
+func BenchmarkScan(b *testing.B) {
+	in := syntheticFasta(64, 1<<20)
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sc := NewScanner(bytes.NewReader(in))
+		for sc.ScanSequence() {
+			sc.Sequence()
+		}
+	}
+}
+func TestWithBufferSize(t *testing.T) {
+	for i := 1; i <= 10; i++ {
+		name := fmt.Sprintf("data/seq%d.fasta", i)
+		in, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := ""
+		for _, s := range scanAll(bytes.NewReader(in)) {
+			want += s.String() + "\n"
+		}
+		get := ""
+		sc := NewScanner(bytes.NewReader(in), WithBufferSize(16))
+		for sc.ScanSequence() {
+			get += sc.Sequence().String() + "\n"
+		}
+		if get != want {
+			t.Errorf("%s:\nwant:\n%s\nget:\n%s\n", name, want, get)
+		}
+	}
+}
+func TestCapacityHint(t *testing.T) {
+	for i := 1; i <= 10; i++ {
+		name := fmt.Sprintf("data/seq%d.fasta", i)
+		get, err := ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, _ := os.Open(name)
+		want := scanAll(f)
+		f.Close()
+		if !reflect.DeepEqual(get, want) {
+			t.Errorf("%s: ReadFile differs from Scanner", name)
+		}
+	}
+	in := syntheticFasta(1, 1<<22)
+	plain := testing.AllocsPerRun(5, func() {
+		scanAll(bytes.NewReader(in))
+	})
+	hinted := testing.AllocsPerRun(5, func() {
+		sc := NewScanner(bytes.NewReader(in),
+			WithCapacityHint(len(in)), WithHandoff())
+		for sc.ScanSequence() {
+			sc.Sequence()
+		}
+	})
+	if hinted >= plain/2 {
+		t.Errorf("want fewer than %v allocations, get %v", plain/2,
+			hinted)
+	}
+}
+func BenchmarkCapacityHint(b *testing.B) {
+	in := syntheticFasta(1, 1<<26)
+	b.SetBytes(int64(len(in)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sc := NewScanner(bytes.NewReader(in),
+			WithCapacityHint(len(in)), WithHandoff())
+		for sc.ScanSequence() {
+			sc.Sequence()
+		}
+	}
+}
+func TestComplementParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	alphabet := "ACGTNRYacgtnry"
+	for _, n := range []int{0, 1, 2, 999, 1000} {
+		d := make([]byte, n)
+		for i := range d {
+			d[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		for _, w := range []int{0, 1, 3, 8, 2000} {
+			want := NewSequence("s", d)
+			get := NewSequence("s", d)
+			want.Complement()
+			get.ComplementParallel(w)
+			if !bytes.Equal(get.Data(), want.Data()) {
+				t.Errorf("complement n=%d w=%d differs", n, w)
+			}
+			want = NewSequence("s", d)
+			get = NewSequence("s", d)
+			want.ReverseComplement()
+			get.ReverseComplementParallel(w)
+			if !bytes.Equal(get.Data(), want.Data()) {
+				t.Errorf("reverse complement n=%d w=%d differs",
+					n, w)
+			}
+		}
+	}
+}
+func BenchmarkReverseComplementParallel(b *testing.B) {
+	s := NewSequence("s", bytes.Repeat([]byte("ACGTN"), 20000000))
+	for _, w := range []int{1, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", w), func(b *testing.B) {
+			b.SetBytes(int64(s.Length()))
+			for i := 0; i < b.N; i++ {
+				s.ReverseComplementParallel(w)
+			}
+		})
+	}
+}
+func TestCounts(t *testing.T) {
+	s := NewSequence("s", []byte("ACgtNNcc"))
+	c := s.Counts()
+	if c['A'] != 1 || c['N'] != 2 || c['c'] != 2 || c['a'] != 0 {
+		t.Errorf("unexpected counts: A=%d N=%d c=%d a=%d", c['A'],
+			c['N'], c['c'], c['a'])
+	}
+	if get := s.GC(); get != 0.5 {
+		t.Errorf("want:\n0.5\nget:\n%v\n", get)
+	}
+}
+func randomResidues(n int) *Sequence {
+	r := rand.New(rand.NewSource(3))
+	d := make([]byte, n)
+	for i := range d {
+		d[i] = "ACGTNacgtn"[r.Intn(10)]
+	}
+	return NewSequence("s", d)
+}
+func BenchmarkGC(b *testing.B) {
+	s := randomResidues(100000000)
+	b.ResetTimer()
+	b.SetBytes(int64(s.Length()))
+	for i := 0; i < b.N; i++ {
+		s.GC()
+	}
+}
+func BenchmarkWriteStatsRow(b *testing.B) {
+	s := randomResidues(100000000)
+	b.ResetTimer()
+	b.SetBytes(int64(s.Length()))
+	for i := 0; i < b.N; i++ {
+		WriteStatsRow(ioutil.Discard, s)
+	}
+}
+func TestFaidx(t *testing.T) {
+	path := t.TempDir() + "/test.fasta"
+	in := ">empty\n>one x\nA\n>seven\nACGTACG\n" +
+		">crlf\r\nACGTACG\r\nTTGCAAT\r\nGG\r\n" +
+		">long\nAAAAACC\nCCCGGGG\nGTTTTTN\nN\n"
+	if err := ioutil.WriteFile(path, []byte(in), 0644); err != nil {
+		t.Fatal(err)
+	}
+	seqs := scanAll(strings.NewReader(in))
+	fx, err := OpenFaidx(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, _ := BuildFai(strings.NewReader(in))
+	fai, err := os.Open(path + ".fai")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get, err := ReadFai(fai)
+	fai.Close()
+	if err != nil || !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	mf, err := OpenMmap(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []*Faidx{fx, mf.Faidx} {
+		for _, s := range seqs {
+			d := s.Data()
+			for i := 0; i <= len(d); i++ {
+				for j := i; j <= len(d); j++ {
+					r, err := f.Fetch(s.ID(), i, j)
+					if err != nil || !bytes.Equal(r.Data(), d[i:j]) {
+						t.Errorf("%s:%d-%d: want %s, get %v, %v",
+							s.ID(), i, j, d[i:j], r, err)
+					}
+				}
+			}
+		}
+		if _, err := f.Fetch("none", 0, 0); err == nil {
+			t.Error("want error for unknown sequence")
+		}
+		if _, err := f.Fetch("long", 0, 23); err == nil {
+			t.Error("want error for range past end")
+		}
+		f.Close()
+		if _, err := f.Fetch("long", 0, 1); err == nil {
+			t.Error("want error after close")
+		}
+	}
+}
+func TestBuildFaiErrors(t *testing.T) {
+	ins := []string{">a\nACG\nACGT\n", ">a\nACG\nA\nACG\n",
+		">a\nACG\n\nACG\n", ">a\nA\n>a\nC\n", "AC\n>a\nA\n"}
+	for _, in := range ins {
+		if _, err := BuildFai(strings.NewReader(in)); err == nil {
+			t.Errorf("want error for %q", in)
+		}
+	}
+}
+func BenchmarkFetch(b *testing.B) {
+	path := b.TempDir() + "/bench.fasta"
+	ioutil.WriteFile(path, syntheticFasta(16, 1<<22), 0644)
+	b.Run("ReadFile", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			seqs, _ := ReadFile(path)
+			seqs[7].Data()
+		}
+	})
+	b.Run("Mmap", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			f, _ := OpenMmap(path)
+			f.Fetch("s8", 1000000, 1001000)
+			f.Close()
+		}
+	})
+}
+func TestReadAllLazy(t *testing.T) {
+	path := t.TempDir() + "/lazy.fasta"
+	in := ">s1 first\nACGTA\nCG\n>s2\nTTTT\n>s3\n>s4\nAC\n"
+	ioutil.WriteFile(path, []byte(in), 0644)
+	want := scanAll(strings.NewReader(in))
+	seqs, err := ReadAllLazy(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range seqs {
+		if s.Length() != want[i].Length() || s.lazy == nil {
+			t.Errorf("%s: want lazy length %d, get %d",
+				s.Header(), want[i].Length(), s.Length())
+		}
+	}
+	if string(seqs[0].Data()) != "ACGTACG" || seqs[0].lazy != nil {
+		t.Errorf("unexpected data %s", seqs[0].Data())
+	}
+	seqs[1].Complement()
+	want[1].Complement()
+	for i, s := range seqs {
+		if err := s.Materialize(); err != nil || !s.Equals(want[i]) {
+			t.Errorf("want:\n%v\nget:\n%v\n", want[i], s)
+		}
+	}
+	seqs, _ = ReadAllLazy(path)
+	os.Remove(path)
+	if err := seqs[0].Materialize(); err == nil {
+		t.Error("want error for missing file")
+	}
+	d := ioutil.Discard
+	gc := func(s *Sequence) float64 { return s.GC() }
+	loads := map[string]func() error{
+		"Concatenate": func() error {
+			_, err := Concatenate(seqs, "")
+			return err
+		},
+		"WriteTab": func() error { return WriteTab(d, seqs) },
+		"Format":   func() error { return Format(d, seqs[0], 60) },
+		"WriteFile": func() error {
+			return WriteFile(path+".out", seqs)
+		},
+		"Writer": func() error {
+			return NewWriter(d, 60).Write(seqs[0])
+		},
+		"FormatWithRuler": func() error {
+			return seqs[0].FormatWithRuler(d, 10)
+		},
+		"Translate": func() error {
+			_, err := seqs[0].Translate(1)
+			return err
+		},
+		"ValidateCDS": func() error {
+			return seqs[0].ValidateCDS(1)
+		},
+		"ExpandIUPAC": func() error {
+			_, err := seqs[0].ExpandIUPAC(10)
+			return err
+		},
+		"KmerJaccard": func() error {
+			_, err := seqs[0].KmerJaccard(seqs[1], 3)
+			return err
+		},
+		"VerifyAlignment": func() error {
+			return VerifyAlignment(seqs)
+		},
+		"WriteBedGraph": func() error {
+			return WriteBedGraph(d, seqs, 2, 2, gc)
+		},
+		"WriteTelomereReport": func() error {
+			return WriteTelomereReport(d, seqs, "TTAGGG", 10)
+		},
+	}
+	for name, f := range loads {
+		if err := f(); err == nil {
+			t.Errorf("%s: want error for missing file", name)
+		}
+	}
+	_, err = ExtractBED(seqs, strings.NewReader("s2\t0\t1\n"))
+	if err == nil || !strings.Contains(err.Error(), "bed line 1") {
+		t.Errorf("ExtractBED: want error on bed line 1, get %v", err)
+	}
+}
+func TestLazyFunctions(t *testing.T) {
+	path := t.TempDir() + "/lazy.fasta"
+	in := ">a x\nACGTNN\nacgt\n>b\nTTTT\n>c\nACGTNNacgt\n" +
+		">d\nGC\n>e\n"
+	ioutil.WriteFile(path, []byte(in), 0644)
+	bed := "a\t1\t5\tr1\t0\t-\nb\t0\t2\n"
+	tests := map[string]func([]*Sequence) interface{}{
+		"MinLength": func(seqs []*Sequence) interface{} {
+			return Filter(seqs, MinLength(4))
+		},
+		"MaxLength": func(seqs []*Sequence) interface{} {
+			return Filter(seqs, MaxLength(4))
+		},
+		"GCBetween": func(seqs []*Sequence) interface{} {
+			return Filter(seqs, GCBetween(0.3, 1))
+		},
+		"MaxNFraction": func(seqs []*Sequence) interface{} {
+			return Filter(seqs, MaxNFraction(0.1))
+		},
+		"SortByLength": func(seqs []*Sequence) interface{} {
+			SortByLength(seqs, true)
+			return seqs
+		},
+		"Stats": func(seqs []*Sequence) interface{} {
+			return Stats(seqs)
+		},
+		"Concatenate": func(seqs []*Sequence) interface{} {
+			c, err := Concatenate(seqs, "|")
+			return fmt.Sprint(c, err)
+		},
+		"ConcatenateMapped": func(seqs []*Sequence) interface{} {
+			c, m, err := ConcatenateMapped(seqs, "|")
+			return fmt.Sprint(c, *m, err)
+		},
+		"Deconcatenate": func(seqs []*Sequence) interface{} {
+			d, err := Deconcatenate(seqs[0], "N")
+			return fmt.Sprint(d, err)
+		},
+		"WriteTab": func(seqs []*Sequence) interface{} {
+			var b bytes.Buffer
+			err := WriteTab(&b, seqs)
+			return fmt.Sprint(b.String(), err)
+		},
+		"WriteStatsRow": func(seqs []*Sequence) interface{} {
+			var b bytes.Buffer
+			err := WriteStatsRow(&b, seqs[0])
+			return fmt.Sprint(b.String(), err)
+		},
+		"DeduplicateByDataFold": func(seqs []*Sequence) interface{} {
+			u, d := DeduplicateByDataFold(seqs)
+			return fmt.Sprint(u, d)
+		},
+		"DeduplicateCanonical": func(seqs []*Sequence) interface{} {
+			u, d := DeduplicateCanonical(seqs)
+			return fmt.Sprint(u, d)
+		},
+		"Partition": func(seqs []*Sequence) interface{} {
+			return fmt.Sprint(Partition(seqs, 3, LengthBin([]int{3, 6})),
+				Partition(seqs, 3, GCBin([]float64{0.4, 0.6})))
+		},
+		"Windows": func(seqs []*Sequence) interface{} {
+			return Windows(seqs, 4, 3)
+		},
+		"ExtractBED": func(seqs []*Sequence) interface{} {
+			x, err := ExtractBED(seqs, strings.NewReader(bed))
+			return fmt.Sprint(x, err)
+		},
+		"MaskBED": func(seqs []*Sequence) interface{} {
+			n, err := MaskBED(seqs, strings.NewReader(bed), true)
+			return fmt.Sprint(seqs, n, err)
+		},
+		"FindContained": func(seqs []*Sequence) interface{} {
+			return FindContained(seqs, seqs, 2)
+		},
+	}
+	for name, f := range tests {
+		seqs, err := ReadAllLazy(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		get := fmt.Sprint(f(seqs))
+		want := fmt.Sprint(f(scanAll(strings.NewReader(in))))
+		if get != want {
+			t.Errorf("%s: want:\n%s\nget:\n%s\n", name, want, get)
+		}
+	}
+	seqs, _ := ReadAllLazy(path)
+	os.Remove(path)
+	var b bytes.Buffer
+	if err := WriteStatsTable(&b, seqs); err == nil {
+		t.Errorf("WriteStatsTable: want error for missing file, "+
+			"get:\n%s", b.String())
+	}
+}
+func TestSequencePooled(t *testing.T) {
+	in := ">a\nACGTACGT\n>b\nTT\n>c\n>d\nGGGGGGGGGGGG\n>e\nC\n"
+	want := scanAll(strings.NewReader(in))
+	for round := 0; round < 3; round++ {
+		sc := NewScanner(strings.NewReader(in))
+		i := 0
+		for sc.ScanSequence() {
+			s := sc.SequencePooled()
+			if !s.Equals(want[i]) || s.String() != want[i].String() {
+				t.Errorf("want:\n%v\nget:\n%v\n", want[i], s)
+			}
+			Recycle(s)
+			i++
+		}
+	}
+}
+func BenchmarkSequencePooled(b *testing.B) {
+	in := syntheticFasta(1000000, 50)
+	b.Run("Sequence", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sc := NewScanner(bytes.NewReader(in))
+			for sc.ScanSequence() {
+				sc.Sequence()
+			}
+		}
+	})
+	b.Run("Pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sc := NewScanner(bytes.NewReader(in))
+			for sc.ScanSequence() {
+				Recycle(sc.SequencePooled())
+			}
+		}
+	})
+}
+func BenchmarkString(b *testing.B) {
+	s := randomResidues(100000000)
+	b.SetBytes(int64(s.Length()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = s.String()
+	}
+}
+func BenchmarkComplement(b *testing.B) {
+	s := randomResidues(100000000)
+	b.SetBytes(int64(s.Length()))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Complement()
+	}
+}
+func TestScannerEdgeCases(t *testing.T) {
+	ins := []string{">a\n\nAC\n", ">a\nAC\n>b", ">a\nAC GT\nA\tC \n"}
+	wants := []string{">a\nAC\n", ">a\nAC\n>b\n", ">a\nACGTAC\n"}
+	for i, in := range ins {
+		get := ""
+		for _, s := range scanAll(strings.NewReader(in)) {
+			get += s.String() + "\n"
+		}
+		if get != wants[i] {
+			t.Errorf("want:\n%q\nget:\n%q\n", wants[i], get)
+		}
+	}
+}
+func TestNoWrap(t *testing.T) {
+	d := bytes.Repeat([]byte("ACGT"), 50)
+	want := ">s\n" + string(d)
+	s := NewSequence("s", d)
+	s.SetLineLength(-1)
+	if s.LineLength() != NoWrap || s.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, s)
+	}
+	s = new(Sequence)
+	s.SetHeader("s")
+	s.SetData(d)
+	if s.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, s)
+	}
+	s.SetData(nil)
+	if s.String() != ">s" {
+		t.Errorf("want:\n>s\nget:\n%s\n", s)
+	}
+}
+func TestBuilder(t *testing.T) {
+	b := NewBuilder("s")
+	b.Grow(10)
+	b.WriteByte('A')
+	b.WriteBytes([]byte("CGT"))
+	if b.Len() != 4 {
+		t.Errorf("want length 4, get %d", b.Len())
+	}
+	s := b.Sequence()
+	b.WriteBytes([]byte("TT"))
+	r := b.Sequence()
+	if s.String() != ">s\nACGT" || r.String() != ">s\nTT" {
+		t.Errorf("unexpected sequences:\n%s\n%s", s, r)
+	}
+	b.WriteByte('A')
+	b.Reset()
+	if b.Sequence().Length() != 0 {
+		t.Error("want empty sequence after reset")
+	}
+}
+func BenchmarkBuilder(b *testing.B) {
+	const n = 50000000
+	b.Run("Builder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			bl := NewBuilder("s")
+			for j := 0; j < n; j++ {
+				bl.WriteByte("ACGT"[j%4])
+			}
+			bl.Sequence()
+		}
+	})
+	b.Run("Append", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			s := NewSequence("s", nil)
+			for j := 0; j < n; j++ {
+				s.SetData(append(s.Data(), "ACGT"[j%4]))
+			}
+		}
+	})
+}
+func TestReaders(t *testing.T) {
+	s := NewSequence("s x", []byte("ACGTACGTAC"))
+	r := NewDataReader(s)
+	d, _ := ioutil.ReadAll(r)
+	r.Seek(2, io.SeekStart)
+	e, _ := ioutil.ReadAll(r)
+	if string(d) != "ACGTACGTAC" || string(e) != "GTACGTAC" {
+		t.Errorf("unexpected data %s, %s", d, e)
+	}
+	for _, l := range []int{0, 1, 3, 5, 10, 11} {
+		s.SetLineLength(l)
+		for _, x := range []*Sequence{s, NewSequence("e", nil)} {
+			x.SetLineLength(l)
+			want := x.String() + "\n"
+			get, _ := ioutil.ReadAll(iotest.OneByteReader(
+				NewFastaReader(x)))
+			if string(get) != want {
+				t.Errorf("want:\n%q\nget:\n%q\n", want, get)
+			}
+		}
+	}
+	s.SetLineLength(3)
+	f := NewFastaReader(s)
+	f.Seek(-5, io.SeekEnd)
+	g, _ := ioutil.ReadAll(f)
+	if string(g) != "TA\nC\n" {
+		t.Errorf("want:\n%q\nget:\n%q\n", "TA\nC\n", g)
+	}
+}
+func TestClone(t *testing.T) {
+	s := NewSequence("s", []byte("AACG"))
+	s.SetLineLength(2)
+	c := s.Clone()
+	if c.LineLength() != 2 || !c.Equals(s) {
+		t.Errorf("want:\n%v\nget:\n%v\n", s, c)
+	}
+	c.ReverseComplement()
+	c.Data()[0] = 'N'
+	c.SetHeader("c")
+	c.SetLineLength(3)
+	if s.String() != ">s\nAA\nCG" {
+		t.Errorf("original changed: %q", s.String())
+	}
+	if c.String() != ">c\nNGT\nT" {
+		t.Errorf("unexpected clone: %q", c.String())
+	}
+}
+func TestDataAliasing(t *testing.T) {
+	d := []byte("ACGT")
+	s := NewSequence("s", d)
+	d[0] = 'N'
+	if string(s.Data()) != "ACGT" {
+		t.Errorf("NewSequence shares data")
+	}
+	s.Data()[1] = 'N'
+	if string(s.Data()) != "ANGT" {
+		t.Errorf("Data doesn't share data")
+	}
+	c := s.DataCopy()
+	c[0] = 'N'
+	if string(s.Data()) != "ANGT" {
+		t.Errorf("DataCopy shares data")
+	}
+	s.SetData(d)
+	d[1] = 'N'
+	if string(s.Data()) != "NNGT" {
+		t.Errorf("SetData doesn't share data")
+	}
+}
+func TestPreview(t *testing.T) {
+	data := []string{"", "A", "ACGT", "ACGTA", "ACGTACGTAC"}
+	want := []string{">s (0 bp)", ">s (1 bp) A", ">s (4 bp) ACGT",
+		">s (5 bp) AC…TA", ">s (10 bp) AC…AC"}
+	for i, d := range data {
+		get := NewSequence("s", []byte(d)).Preview(2)
+		if get != want[i] {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], get)
+		}
+	}
+	if get := NewSequence("s", []byte("AC")).Preview(0); get !=
+		">s (2 bp) …" {
+		t.Errorf("want:\n>s (2 bp) …\nget:\n%s\n", get)
+	}
+	nums := []int{0, 999, 1000, -1234567, 248956422}
+	strs := []string{"0", "999", "1,000", "-1,234,567", "248,956,422"}
+	for i, n := range nums {
+		if get := formatThousands(n); get != strs[i] {
+			t.Errorf("want:\n%s\nget:\n%s\n", strs[i], get)
+		}
+	}
+}
+func TestFormatWithRuler(t *testing.T) {
+	s := NewSequence("s", bytes.Repeat([]byte("ACGT"), 6))
+	var b bytes.Buffer
+	s.FormatWithRuler(&b, 20)
+	want := ">s\n" +
+		"                10        20\n" +
+		"        ----:----|----:----|\n" +
+		"      1 ACGTACGTACGTACGTACGT\n" +
+		"     21 ACGT\n"
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+	b.Reset()
+	s.FormatWithRulerGroups(&b, 12, 3)
+	lines := strings.Split(b.String(), "\n")
+	wantLines := []string{">s",
+		"                   10",
+		"        --- -:- --- |--",
+		"      1 ACG TAC GTA CGT",
+		"     13 ACG TAC GTA CGT", ""}
+	if !reflect.DeepEqual(lines, wantLines) {
+		t.Errorf("want:\n%q\nget:\n%q\n", wantLines, lines)
+	}
+	if p := rulerPad(2000000000); p != 10 {
+		t.Errorf("want pad 10, get %d", p)
+	}
+	if err := s.FormatWithRuler(&b, 0); err == nil {
+		t.Error("want error for width 0")
+	}
+}
+func TestMeta(t *testing.T) {
+	s := NewSequence("s1 chromosome", []byte("ACGTACGT"))
+	if s.meta != nil || len(s.MetaKeys()) != 0 {
+		t.Error("want no metadata map before first use")
+	}
+	if _, ok := s.Meta("taxon"); ok {
+		t.Error("want unset key")
+	}
+	s.SetMeta("taxon", "E.coli")
+	s.SetMeta("coverage", 31.5)
+	if v, ok := s.Meta("coverage"); !ok || v.(float64) != 31.5 {
+		t.Errorf("want coverage 31.5, get %v", v)
+	}
+	keys := s.MetaKeys()
+	if !reflect.DeepEqual(keys, []string{"coverage", "taxon"}) {
+		t.Errorf("want sorted keys, get %v", keys)
+	}
+	c := s.Clone()
+	c.SetMeta("taxon", "B.subtilis")
+	if v, _ := s.Meta("taxon"); v != "E.coli" {
+		t.Errorf("clone shares metadata map: %v", v)
+	}
+	wins := Windows([]*Sequence{s}, 4, 4)
+	for _, w := range wins {
+		if v, _ := w.Meta("taxon"); v != "E.coli" {
+			t.Errorf("%s: want taxon E.coli, get %v", w.Header(), v)
+		}
+	}
+	h := s.HeaderWithMeta()
+	want := "s1 chromosome coverage=31.5 taxon=E.coli"
+	if h != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, h)
+	}
+	r := NewSequence(h, nil)
+	if n := r.MetaFromHeader(); n != 2 {
+		t.Errorf("want 2 attributes, get %d", n)
+	}
+	if r.Header() != "s1 chromosome" {
+		t.Errorf("want header s1 chromosome, get %q", r.Header())
+	}
+	if v, _ := r.Meta("coverage"); v != "31.5" {
+		t.Errorf("want coverage \"31.5\", get %v", v)
+	}
+}
+func TestFormat(t *testing.T) {
+	s := NewSequence("s", []byte("ACGTACGTAC"))
+	s.SetLineLength(4)
+	e := NewSequence("e", nil)
+	for _, x := range []*Sequence{s, e} {
+		var b bytes.Buffer
+		Format(&b, x, KeepLineLength)
+		if want := x.String() + "\n"; b.String() != want {
+			t.Errorf("want:\n%q\nget:\n%q\n", want, b.String())
+		}
+	}
+	var b bytes.Buffer
+	Format(&b, s, 6)
+	want := ">s\nACGTAC\nGTAC\n"
+	if b.String() != want {
+		t.Errorf("want:\n%q\nget:\n%q\n", want, b.String())
+	}
+	b.Reset()
+	w := NewWriter(&b, NoWrap)
+	w.Write(s)
+	w.Write(e)
+	w.Flush()
+	want = ">s\nACGTACGTAC\n>e\n"
+	if b.String() != want {
+		t.Errorf("want:\n%q\nget:\n%q\n", want, b.String())
+	}
+	if s.LineLength() != 4 {
+		t.Errorf("writing changed line length to %d", s.LineLength())
+	}
+	o := NewSequence("s", []byte("ACGTACGTAC"))
+	if !s.Equals(o) {
+		t.Error("Equals should ignore line lengths")
+	}
+}
+func TestPreserveWrapping(t *testing.T) {
+	roundTrip := func(in []byte) []byte {
+		var b bytes.Buffer
+		sc := NewScanner(bytes.NewReader(in),
+			WithPreserveWrapping())
+		w := NewWriter(&b, KeepLineLength)
+		for sc.ScanSequence() {
+			w.Write(sc.Sequence())
+		}
+		w.Flush()
+		return b.Bytes()
+	}
+	for i := 2; i <= 9; i++ {
+		name := "data/seq" + strconv.Itoa(i) + ".fasta"
+		in, _ := ioutil.ReadFile(name)
+		if len(in) > 0 && in[len(in)-1] != '\n' {
+			in = append(in, '\n')
+		}
+		var r bytes.Buffer
+		w := NewWriter(&r, 60)
+		for _, s := range scanAll(bytes.NewReader(in)) {
+			w.Write(s)
+		}
+		w.Flush()
+		for _, x := range [][]byte{in, r.Bytes()} {
+			if out := roundTrip(x); !bytes.Equal(out, x) {
+				t.Errorf("failed to reproduce %q\n", name)
+			}
+		}
+	}
+	in := ">a\nACG\nTAC\nG\n>b\nAC\nGTA\n>c\nACG\nT\nA\n>d\n"
+	sc := NewScanner(strings.NewReader(in), WithPreserveWrapping())
+	var get []string
+	for sc.ScanSequence() {
+		s := sc.Sequence()
+		get = append(get, fmt.Sprintf("%s:%d:%t", s.Header(),
+			s.LineLength(), sc.WrapInconsistent()))
+	}
+	want := []string{"a:3:false", "b:2:true", "c:3:true",
+		"d:70:false"}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+}
+func TestSentinelErrors(t *testing.T) {
+	rd := strings.NewReader
+	a := NewSequence("a", []byte("ACGT"))
+	dup := ">a\nAC\n>a\nGT\n"
+	fetch := func(name string) error {
+		d := ">a\nACGT\n"
+		entries, _ := BuildFai(rd(d))
+		_, err := NewFaidx(rd(d), entries).Fetch(name, 0, 1)
+		return err
+	}
+	tests := []struct {
+		name string
+		want error
+		f    func() error
+	}{
+		{"ReadQual header", ErrNoHeader, func() error {
+			_, err := ReadQual(rd("30 30\n>a\n30\n"))
+			return err
+		}},
+		{"BuildFai header", ErrNoHeader, func() error {
+			_, err := BuildFai(rd("AC\n>a\nGT\n"))
+			return err
+		}},
+		{"QualityEncoding", ErrEmptyRecord, func() error {
+			q := &QualSequence{Sequence: *NewSequence("e", nil)}
+			_, err := q.QualityEncoding()
+			return err
+		}},
+		{"ReadQual duplicate", ErrDuplicateID, func() error {
+			_, err := ReadQual(rd(">a\n30\n>a\n30\n"))
+			return err
+		}},
+		{"Diff", ErrDuplicateID, func() error {
+			_, err := Diff(rd(dup), rd(">a\nAC\n"))
+			return err
+		}},
+		{"Merge", ErrDuplicateID, func() error {
+			_, err := Merge(ioutil.Discard, MergeError, rd(dup))
+			return err
+		}},
+		{"Merge identical", ErrDuplicateID, func() error {
+			_, err := Merge(ioutil.Discard, KeepIfIdentical,
+				rd(dup))
+			return err
+		}},
+		{"Reorder duplicate", ErrDuplicateID, func() error {
+			_, err := Reorder([]*Sequence{a, a}, nil, false)
+			return err
+		}},
+		{"RenameFromMap", ErrDuplicateID, func() error {
+			b := NewSequence("b", nil)
+			_, err := RenameFromMap([]*Sequence{a, b},
+				rd("a\tc\nb\tc\n"), false)
+			return err
+		}},
+		{"BuildFai duplicate", ErrDuplicateID, func() error {
+			_, err := BuildFai(rd(dup))
+			return err
+		}},
+		{"NewQualSequence", ErrUnequalLengths, func() error {
+			_, err := NewQualSequence("q", []byte("AC"),
+				[]byte("I"))
+			return err
+		}},
+		{"FastqScanner", ErrUnequalLengths, func() error {
+			sc := NewFastqScanner(rd("@r\nACG\n+\nIIII\n"))
+			for sc.ScanRecord() {
+			}
+			return sc.Err()
+		}},
+		{"AttachQual", ErrUnequalLengths, func() error {
+			_, err := AttachQual([]*Sequence{a},
+				map[string][]int{"a": {30}})
+			return err
+		}},
+		{"WriteFastq", ErrUnequalLengths, func() error {
+			q := &QualSequence{Sequence: *a}
+			return WriteFastq(ioutil.Discard, []*QualSequence{q})
+		}},
+		{"Reorder unknown", ErrUnknownSequence, func() error {
+			_, err := Reorder([]*Sequence{a}, []string{"a", "b"},
+				true)
+			return err
+		}},
+		{"ExtractBED", ErrUnknownSequence, func() error {
+			_, err := ExtractBED([]*Sequence{a}, rd("b\t0\t1\n"))
+			return err
+		}},
+		{"Fetch", ErrUnknownSequence, func() error {
+			return fetch("b")
+		}},
+	}
+	for _, test := range tests {
+		if err := test.f(); !errors.Is(err, test.want) {
+			t.Errorf("%s: want %v, get %v", test.name,
+				test.want, err)
+		}
+	}
+	var err error = &ErrInvalidResidue{ID: "a", Line: 2, Offset: 5,
+		Byte: '!'}
+	err = fmt.Errorf("reading: %w", err)
+	if !errors.Is(err, &ErrInvalidResidue{}) {
+		t.Errorf("errors.Is doesn't detect %v", err)
+	}
+	var ir *ErrInvalidResidue
+	if !errors.As(err, &ir) || ir.Offset != 5 || ir.Byte != '!' {
+		t.Errorf("errors.As doesn't recover %v", err)
+	}
+	want := "reading: \"a\": invalid residue '!' at line 2, offset 5"
+	if err.Error() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, err)
+	}
+	if errors.Is(ErrDuplicateID, ErrUnknownSequence) {
+		t.Error("distinct sentinels match")
+	}
+}
+func TestTranslate(t *testing.T) {
+	s := NewSequence("g", []byte("ATGgcuTGGAAATAGCA"))
+	p, err := s.Translate(1)
+	if err != nil || string(p.Data()) != "MAWK*" ||
+		p.Header() != "g" {
+		t.Errorf("want MAWK*, get %v, %v", p, err)
+	}
+	tests := []struct {
+		codon string
+		table int
+		want  byte
+	}{
+		{"GCN", 1, 'A'},
+		{"ggn", 1, 'G'},
+		{"YTR", 1, 'L'},
+		{"MGR", 1, 'R'},
+		{"RAY", 1, 'X'},
+		{"YTN", 1, 'X'},
+		{"TAR", 1, '*'},
+		{"TRA", 1, '*'},
+		{"TRA", 2, 'X'},
+		{"TGR", 2, 'W'},
+		{"AGR", 2, '*'},
+		{"TAN", 1, 'X'},
+		{"NNN", 1, 'X'},
+		{"A-G", 1, 'X'},
+		{"ATH", 1, 'I'},
+		{"ATH", 2, 'X'},
+	}
+	for _, test := range tests {
+		s := NewSequence("c", []byte(test.codon))
+		p, _ := s.Translate(test.table)
+		if g := p.Data()[0]; g != test.want {
+			t.Errorf("%s in code %d: want %c, get %c",
+				test.codon, test.table, test.want, g)
+		}
+	}
+	if _, err := s.Translate(7); err == nil {
+		t.Error("want error for unknown genetic code")
+	}
+}
+func TestStartStopCodons(t *testing.T) {
+	s := NewSequence("s", []byte("TAatgTAGgtgCTAAtgA"))
+	get := s.FindStartCodons(nil)
+	if want := []int{2, 14}; !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	get = s.FindStartCodons([]string{"ATG", "gtg", "TTG"})
+	if want := []int{2, 8, 14}; !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	want := map[int][]int{
+		1:  {0, 12, 15},
+		2:  nil,
+		3:  {5},
+		-1: nil,
+		-2: {11},
+		-3: nil,
+	}
+	for f, w := range want {
+		get := s.FindStopCodons(1, f)
+		if len(get) != len(w) || len(w) > 0 &&
+			!reflect.DeepEqual(get, w) {
+			t.Errorf("frame %d: want %v, get %v", f, w, get)
+		}
+	}
+	cds := []struct {
+		seq   string
+		table int
+		ok    bool
+	}{
+		{"ATGAAATAA", 1, true},
+		{"atgaaatag", 1, true},
+		{"GTGAAATGA", 1, false},
+		{"GTGAAATGA", 11, true},
+		{"ATGTAAAAATAA", 1, false},
+		{"ATGAAAAAA", 1, false},
+		{"ATGAAATA", 1, false},
+		{"ATGAAATGA", 2, false},
+	}
+	for _, c := range cds {
+		err := NewSequence("c", []byte(c.seq)).ValidateCDS(c.table)
+		if (err == nil) != c.ok {
+			t.Errorf("%s in code %d: unexpected error %v",
+				c.seq, c.table, err)
+		}
+	}
+	err := NewSequence("c", []byte("ATGTAATGATAA")).ValidateCDS(1)
+	if err == nil || !strings.HasSuffix(err.Error(),
+		"internal stop codons at 3, 6") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+func TestCAI(t *testing.T) {
+	ref := []*Sequence{
+		NewSequence("r1", []byte("ATGGCTGCTGGTGGTTAA")),
+		NewSequence("r2", []byte("ATGGCTGGTGGCTAA")),
+	}
+	w, err := CodonWeights(ref, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]float64{"GCT": 1, "GCC": 0.5 / 3.5,
+		"GGT": 1, "GGC": 1.5 / 3.5}
+	for c, x := range want {
+		if math.Abs(w[c]-x) > 1e-12 {
+			t.Errorf("%s: want weight %g, get %g", c, x, w[c])
+		}
+	}
+	if _, ok := w["ATG"]; ok {
+		t.Error("ATG shouldn't be weighted")
+	}
+	g := NewSequence("g", []byte("ATGGCTGCTggtTAA"))
+	if c, err := g.CAI(w, 1); err != nil || math.Abs(c-1) > 1e-12 {
+		t.Errorf("want CAI 1, get %g, %v", c, err)
+	}
+	g = NewSequence("g", []byte("ATGGCTGGCNNNGCRTAA"))
+	c, used, excl, err := g.CAICount(w, 1)
+	wc := math.Sqrt(1.5 / 3.5)
+	if err != nil || used != 2 || excl != 2 ||
+		math.Abs(c-wc) > 1e-12 {
+		t.Errorf("want %g, 2, 2, get %g, %d, %d, %v", wc, c, used,
+			excl, err)
+	}
+	g = NewSequence("g", []byte("ATGGCTG"))
+	if _, err := g.CAI(w, 1); err == nil {
+		t.Error("want error for partial codon")
+	}
+	if _, err := CodonWeights([]*Sequence{g}, 1); err == nil {
+		t.Error("want error for partial codon in reference")
+	}
+}
+func TestGCSkew(t *testing.T) {
+	s := NewSequence("s", []byte("GgCANc-G"))
+	get := s.CumulativeGCSkew()
+	want := []float64{1, 2, 1, 1, 1, 0, 0, 1}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	get = s.CumulativeGCSkewStride(3)
+	want = []float64{1, 0, 1}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	var b []byte
+	b = append(b, bytes.Repeat([]byte("GCCA"), 100)...)
+	b = append(b, bytes.Repeat([]byte("GGCT"), 150)...)
+	b = append(b, bytes.Repeat([]byte("GCCA"), 50)...)
+	c := NewSequence("c", b)
+	ori, ter := c.PredictOriTer()
+	if ori < 390 || ori > 400 || ter < 990 || ter > 1000 {
+		t.Errorf("want ori near 400 and ter near 1000, get %d, %d",
+			ori, ter)
+	}
+	n := len(b)
+	for _, r := range []int{1, 123, 777, 1100} {
+		rb := append(append([]byte{}, b[r:]...), b[:r]...)
+		o, e := NewSequence("r", rb).PredictOriTer()
+		if o != (ori-r+n)%n || e != (ter-r+n)%n {
+			t.Errorf("rotation %d: want %d, %d, get %d, %d", r,
+				(ori-r+n)%n, (ter-r+n)%n, o, e)
+		}
+	}
+}
+func TestFindTandemRepeats(t *testing.T) {
+	s := NewSequence("s", []byte("GTcaCACAcACGAAANAAAAGCTGCTGCTG"))
+	get := s.FindTandemRepeats(1, 4, 3)
+	want := []TandemRepeat{
+		{2, 10, "ca", 4},
+		{12, 15, "A", 3},
+		{16, 20, "A", 4},
+		{20, 29, "GCT", 3},
+	}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	get = s.FindTandemRepeats(2, 2, 2)
+	want = []TandemRepeat{{2, 10, "ca", 4}}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	r := randomResidues(5000000)
+	r.SetData(append(r.Data(), bytes.Repeat([]byte("AGGTC"), 20)...))
+	reps := r.FindTandemRepeats(2, 8, 10)
+	if len(reps) != 1 || reps[0].Copies != 20 {
+		t.Errorf("want one repeat of 20 copies, get %v", reps)
+	}
+}
+func TestFindPalindromes(t *testing.T) {
+	tests := []struct {
+		seq                    string
+		minLen, maxLen, spacer int
+		want                   []Palindrome
+	}{
+		{"ccGAATTCcc", 6, 6, 0, []Palindrome{{2, 6, 0}}},
+		{"ccgaattcGG", 6, 12, 0, []Palindrome{{0, 10, 0}}},
+		{"GAATTCGAATTC", 6, 6, 0,
+			[]Palindrome{{0, 6, 0}, {3, 6, 0}, {6, 6, 0}}},
+		{"GAATTCGAATTC", 6, 12, 0, []Palindrome{{0, 12, 0}}},
+		{"aGCCGCTTTGCGGCa", 10, 10, 3,
+			[]Palindrome{{1, 13, 3}}},
+		{"aGCCGCTTTGCGGCa", 10, 10, 2, nil},
+		{"GAANTC", 2, 6, 0, nil},
+		{"GAANTC", 2, 6, 1, []Palindrome{{2, 3, 1}}},
+	}
+	for _, test := range tests {
+		s := NewSequence("s", []byte(test.seq))
+		get := s.FindNearPalindromes(test.minLen, test.maxLen,
+			test.spacer)
+		if !reflect.DeepEqual(get, test.want) {
+			t.Errorf("%s: want %v, get %v", test.seq, test.want,
+				get)
+		}
+	}
+}
+func TestTelomereContent(t *testing.T) {
+	var b []byte
+	b = append(b, bytes.Repeat([]byte("CTAACC"), 10)...)
+	b = append(b, bytes.Repeat([]byte("ACGGTCA"), 20)...)
+	b = append(b, "GCGTCAGTGCGCAACGTGCCAATGGTACGT"...)
+	b = append(b, bytes.Repeat([]byte("ttaggg"), 5)...)
+	s := NewSequence("chr1 test", b)
+	f, e := s.TelomereContent("", 60)
+	if f != 1 || e != 0.5 {
+		t.Errorf("want 1, 0.5, get %g, %g", f, e)
+	}
+	s = NewSequence("short", []byte("TTAGGGTTAGGGTTA"))
+	f, e = s.TelomereContent("TTAGGG", 100)
+	if f != 0 || e != 1 {
+		t.Errorf("want 0, 1, get %g, %g", f, e)
+	}
+	s = NewSequence("n", bytes.Repeat([]byte("N"), 100))
+	if f, e = s.TelomereContent("", 60); f != 0 || e != 0 {
+		t.Errorf("want 0, 0, get %g, %g", f, e)
+	}
+	s = NewSequence("single", []byte("ACGTTAGGGCCA"))
+	if f, e = s.TelomereContent("", 12); f != 0 || e != 0 {
+		t.Errorf("want 0, 0, get %g, %g", f, e)
+	}
+	var w bytes.Buffer
+	WriteTelomereReport(&w, []*Sequence{
+		NewSequence("a", []byte("CCCTAACCCTAAACGT")),
+		NewSequence("e", nil)}, "", 12)
+	want := TelomereReportHeader + "\n" +
+		"a\t16\t1.0000\t0.0000\n" +
+		"e\t0\t0.0000\t0.0000\n"
+	if w.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, w.String())
+	}
+}
+func TestFindSTRs(t *testing.T) {
+	in := ">chr1 first\nGTcacacacacaGTAAAAAAAGC\n" +
+		">chr2\nAGATAGATAGATAGATCTTTC\n"
+	seqs := scanAll(strings.NewReader(in))
+	get := FindSTRs(seqs, []int{1, 2, 4}, 6)
+	want := []STR{
+		{"chr1", 2, 12, "CA", 5},
+		{"chr1", 14, 21, "A", 7},
+		{"chr2", 0, 16, "AGAT", 4},
+	}
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	var b bytes.Buffer
+	err := FindSTRsStream(strings.NewReader(in), &b, []int{1, 2, 4},
+		6)
+	bed := "chr1\t2\t12\t(CA)5\n" +
+		"chr1\t14\t21\t(A)7\n" +
+		"chr2\t0\t16\t(AGAT)4\n"
+	if err != nil || b.String() != bed {
+		t.Errorf("want:\n%s\nget:\n%s\n", bed, b.String())
+	}
+}
+func TestSketch(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	random := func(n int) []byte {
+		d := make([]byte, n)
+		for i := range d {
+			d[i] = "ACGT"[r.Intn(4)]
+		}
+		return d
+	}
+	a := NewSequence("a", random(20000))
+	rc := a.Clone()
+	rc.ReverseComplement()
+	half := NewSequence("h", append(a.DataCopy()[:10000],
+		random(10000)...))
+	other := NewSequence("o", random(20000))
+	sa := a.Sketch(21, 1000)
+	if sa.Len() != 1000 {
+		t.Errorf("want 1000 hashes, get %d", sa.Len())
+	}
+	if j := sa.Jaccard(a.Clone().Sketch(21, 1000)); j != 1 {
+		t.Errorf("copy: want Jaccard 1, get %g", j)
+	}
+	if j := sa.Jaccard(rc.Sketch(21, 1000)); j != 1 {
+		t.Errorf("reverse complement: want Jaccard 1, get %g", j)
+	}
+	sh := half.Sketch(21, 1000)
+	if j := sa.Jaccard(sh); j < 0.25 || j > 0.42 {
+		t.Errorf("half: want Jaccard near 1/3, get %g", j)
+	}
+	j := sa.Jaccard(sh)
+	d := -math.Log(2*j/(1+j)) / 21
+	if sa.Distance(sh) != d {
+		t.Errorf("want distance %g, get %g", d, sa.Distance(sh))
+	}
+	so := other.Sketch(21, 1000)
+	if sa.Jaccard(so) != 0 || sa.Distance(so) != 1 {
+		t.Errorf("unrelated: want 0, 1, get %g, %g",
+			sa.Jaccard(so), sa.Distance(so))
+	}
+	b1, _ := sa.MarshalBinary()
+	b2, _ := a.Sketch(21, 1000).MarshalBinary()
+	if !bytes.Equal(b1, b2) {
+		t.Error("sketching isn't deterministic")
+	}
+	b3, _ := NewSketch([]*Sequence{a}, 21, 1000, 1).MarshalBinary()
+	if bytes.Equal(b1, b3) {
+		t.Error("seed has no effect")
+	}
+	var c MinHashSketch
+	if err := c.UnmarshalBinary(b1); err != nil ||
+		!reflect.DeepEqual(&c, sa) {
+		t.Errorf("round trip failed: %v", err)
+	}
+	if err := c.UnmarshalBinary(b1[:len(b1)-1]); err == nil {
+		t.Error("want error for truncated sketch")
+	}
+	for _, n := range []int{1, 1, 1, 2, 2, 3, 5, 10} {
+		unit := string(random(n))
+		rep := NewSequence("r", []byte(strings.Repeat(unit, 1000/n)))
+		x := NewSequence("x", random(150))
+		b1, _ := NewSketch([]*Sequence{rep, x}, 21, 100,
+			DefaultSketchSeed).MarshalBinary()
+		b2, _ := NewSketch([]*Sequence{x, rep}, 21, 100,
+			DefaultSketchSeed).MarshalBinary()
+		all := NewSketch([]*Sequence{rep, x}, 21, 100000,
+			DefaultSketchSeed)
+		all.size = 100
+		all.hashes = all.hashes[:100]
+		b3, _ := all.MarshalBinary()
+		if !bytes.Equal(b1, b2) || !bytes.Equal(b2, b3) {
+			t.Errorf("%s repeat: sketch depends on input order",
+				unit)
+		}
+	}
+}
+func TestKmerJaccard(t *testing.T) {
+	s := NewSequence("s", []byte("ACGTTGCAAGGCTTAGCA"))
+	rc := s.Clone()
+	rc.ReverseComplement()
+	for _, o := range []*Sequence{s, rc} {
+		if j, err := s.KmerJaccard(o, 5); j != 1 || err != nil {
+			t.Errorf("want 1, get %g, %v", j, err)
+		}
+	}
+	a := NewSequence("a", []byte("ACGTT"))
+	b := NewSequence("b", []byte("ACGGA"))
+	if j, _ := a.KmerJaccard(b, 3); j != 0.25 {
+		t.Errorf("want 0.25, get %g", j)
+	}
+	c := NewSequence("c", []byte("AACNGGA"))
+	if j, _ := c.KmerJaccard(a, 3); j != 1.0/3 {
+		t.Errorf("want 1/3, get %g", j)
+	}
+	if _, err := a.KmerJaccard(b, 33); err == nil {
+		t.Error("want error for k = 33")
+	}
+}
+func TestShuffleAll(t *testing.T) {
+	s := NewSequence("s", []byte("AACCGGTTAACCGGTT"))
+	s.Shuffle(nil)
+	c := s.Counts()
+	if s.Length() != 16 || c['A'] != 4 || c['G'] != 4 {
+		t.Errorf("shuffle changed composition: %s", s.Data())
+	}
+	a, b := s.Clone(), s.Clone()
+	a.Shuffle(SeededRand(3))
+	b.Shuffle(SeededRand(3))
+	if !a.Equals(b) {
+		t.Error("seeded shuffles differ")
+	}
+	var seqs []*Sequence
+	for i := 0; i < 20; i++ {
+		seqs = append(seqs, NewSequence(strconv.Itoa(i), nil))
+	}
+	sh := append([]*Sequence{}, seqs...)
+	ShuffleAll(sh, SeededRand(1))
+	SortByHeader(sh)
+	for i := range sh {
+		if sh[i] != seqs[i] {
+			t.Fatalf("ShuffleAll lost or duplicated sequences")
+		}
+	}
+	ShuffleAll(sh, nil)
+	if len(Sample(sh, 5, nil)) != 5 {
+		t.Error("want sample of 5 with nil source")
+	}
+}
+func TestNonMutating(t *testing.T) {
+	s := NewSequence("s desc", []byte("AcgTNRy-"))
+	s.SetLineLength(3)
+	s.SetMeta("k", 1)
+	orig := s.DataCopy()
+	tests := []struct {
+		name string
+		f    func() *Sequence
+		mut  func(*Sequence)
+	}{
+		{"Reversed", s.Reversed, (*Sequence).Reverse},
+		{"Complemented", s.Complemented, (*Sequence).Complement},
+		{"ReverseComplemented", s.ReverseComplemented,
+			(*Sequence).ReverseComplement},
+		{"Upper", s.Upper, func(x *Sequence) {
+			x.SetData(bytes.ToUpper(x.Data()))
+		}},
+	}
+	for _, test := range tests {
+		get := test.f()
+		want := s.Clone()
+		test.mut(want)
+		if !get.Equals(want) || get.LineLength() != 3 {
+			t.Errorf("%s: want %s, get %s", test.name, want, get)
+		}
+		if v, _ := get.Meta("k"); v != 1 {
+			t.Errorf("%s: metadata lost", test.name)
+		}
+		if !bytes.Equal(s.Data(), orig) {
+			t.Fatalf("%s changed the receiver to %s", test.name,
+				s.Data())
+		}
+	}
+}
+func TestFreeze(t *testing.T) {
+	s := NewSequence("s", []byte("ACGTACGT"))
+	s.Freeze()
+	q := &QualSequence{Sequence: *s, quality: []byte("IIIIIIII")}
+	muts := map[string]func(){
+		"SetHeader":         func() { s.SetHeader("x") },
+		"SetData":           func() { s.SetData(nil) },
+		"SetLineLength":     func() { s.SetLineLength(3) },
+		"AppendToHeader":    func() { s.AppendToHeader("x") },
+		"Shuffle":           func() { s.Shuffle(SeededRand(1)) },
+		"Reverse":           func() { s.Reverse() },
+		"Complement":        func() { s.Complement() },
+		"ReverseComplement": func() { s.ReverseComplement() },
+		"ComplementParallel": func() {
+			s.ComplementParallel(2)
+		},
+		"ReverseComplementParallel": func() {
+			s.ReverseComplementParallel(2)
+		},
+		"SetMeta":          func() { s.SetMeta("k", 1) },
+		"MetaFromHeader":   func() { s.MetaFromHeader() },
+		"TrimQuality":      func() { q.TrimQuality(20) },
+		"SetQuality":       func() { q.SetQuality(nil) },
+		"SetQualityOffset": func() { q.SetQualityOffset(64) },
+		"Recycle":          func() { Recycle(s) },
+		"Replace":          func() { s.Replace('A', 'T') },
+		"ReplaceFold":      func() { s.ReplaceFold('a', 'T') },
+		"ReplaceFunc": func() {
+			s.ReplaceFunc(func(c byte) byte { return c })
+		},
+	}
+	for name, f := range muts {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", name)
+				}
+			}()
+			f()
+		}()
+	}
+	if s.String() != ">s\nACGTACGT" {
+		t.Errorf("frozen sequence changed to %s", s)
+	}
+	seqs := []*Sequence{s}
+	_, err := MaskBED(seqs, strings.NewReader("s\t0\t2\n"), true)
+	if !errors.Is(err, ErrFrozen) {
+		t.Errorf("MaskBED: want ErrFrozen, get %v", err)
+	}
+	_, err = RenameFromMap(seqs, strings.NewReader("s\tt\n"), false)
+	if !errors.Is(err, ErrFrozen) {
+		t.Errorf("RenameFromMap: want ErrFrozen, get %v", err)
+	}
+	if !s.Clone().Frozen() {
+		t.Error("clone of frozen sequence isn't frozen")
+	}
+	m := s.Thaw()
+	m.Complement()
+	if m.Frozen() || !s.Frozen() || string(m.Data()) != "TGCATGCA" ||
+		string(s.Data()) != "ACGTACGT" {
+		t.Errorf("thawing failed: %s, %s", m.Data(), s.Data())
+	}
+}
+func TestStdin(t *testing.T) {
+	in := ">a\nACGT\n>b\nTT\n"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(in))
+	zw.Close()
+	want := ">a\nACGT\n>b\nTT\n"
+	for _, data := range [][]byte{[]byte(in), gz.Bytes()} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		go func(d []byte) {
+			w.Write(d)
+			w.Close()
+		}(data)
+		stdin := os.Stdin
+		os.Stdin = r
+		seqs, err := ReadFile("-")
+		os.Stdin = stdin
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		get := ""
+		for _, s := range seqs {
+			get += s.String() + "\n"
+		}
+		if get != want {
+			t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+		}
+	}
+	if _, err := OpenFaidx("-"); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("OpenFaidx: want ErrNotSeekable, get %v", err)
+	}
+	if _, err := ReadAllLazy("-"); !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("ReadAllLazy: want ErrNotSeekable, get %v", err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = checkSeekable("pipe", r)
+	if !errors.Is(err, ErrNotSeekable) {
+		t.Errorf("pipe: want ErrNotSeekable, get %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	seqs := []*Sequence{NewSequence("a", []byte("ACGT"))}
+	err = WriteFile("-", seqs)
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != ">a\nACGT\n" {
+		t.Errorf("want:\n>a\nACGT\n\nget:\n%s\n", out)
+	}
+}
+func TestReadURL(t *testing.T) {
+	in := ">a\nACGT\n>b\nTT\n"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(in))
+	zw.Close()
+	cancelled := make(chan bool)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plain.fa", func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Write([]byte(in))
+	})
+	mux.HandleFunc("/file.fa.gz", func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Write(gz.Bytes())
+	})
+	mux.HandleFunc("/encoded.fa", func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gz.Bytes())
+	})
+	mux.HandleFunc("/hang.fa", func(w http.ResponseWriter,
+		r *http.Request) {
+		w.Write([]byte(">a\nAC"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(cancelled)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	ctx := context.Background()
+	for _, p := range []string{"/plain.fa", "/file.fa.gz",
+		"/encoded.fa"} {
+		seqs, err := ReadURL(ctx, ts.URL+p)
+		if err != nil {
+			t.Fatalf("%s: %v", p, err)
+		}
+		get := ""
+		for _, s := range seqs {
+			get += s.String() + "\n"
+		}
+		if get != in {
+			t.Errorf("%s: want:\n%s\nget:\n%s\n", p, in, get)
+		}
+	}
+	_, err := ReadURL(ctx, ts.URL+"/missing.fa")
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != 404 {
+		t.Errorf("want HTTPError 404, get %v", err)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+	_, err = ReadURL(ctx, ts.URL+"/hang.fa")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, get %v", err)
+	}
+	<-cancelled
+}
+func TestFetchNCBI(t *testing.T) {
+	known := map[string]string{"A1": "ACGT", "B2": "GG", "C3": "T",
+		"D4": "CC"}
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(
+		w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.FormValue("db") != "nuccore" ||
+			r.FormValue("rettype") != "fasta" ||
+			r.FormValue("api_key") != "key" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, a := range strings.Split(r.FormValue("id"), ",") {
+			if d, ok := known[a]; ok {
+				fmt.Fprintf(w, ">%s.1 test\n%s\n", a, d)
+			}
+		}
+	}))
+	defer ts.Close()
+	url, size, backoff := ncbiEfetchURL, ncbiBatchSize, ncbiBackoff
+	ncbiEfetchURL, ncbiBatchSize, ncbiBackoff = ts.URL, 2,
+		time.Millisecond
+	defer func() {
+		ncbiEfetchURL, ncbiBatchSize, ncbiBackoff = url, size,
+			backoff
+	}()
+	acc := []string{"A1", "B2", "X9", "C3", "D4"}
+	seqs, err := FetchNCBI(context.Background(), "nuccore", acc,
+		"key")
+	var me *MissingAccessionsError
+	if !errors.As(err, &me) || len(me.Accessions) != 1 ||
+		me.Accessions[0] != "X9" {
+		t.Errorf("want missing X9, get %v", err)
+	}
+	get := ""
+	for _, s := range seqs {
+		get += s.ID() + " "
+	}
+	want := "A1.1 B2.1 C3.1 D4.1 "
+	if get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+	if requests != 4 {
+		t.Errorf("want 4 requests, get %d", requests)
+	}
+	_, err = FetchNCBI(context.Background(), "nuccore", acc, "")
+	var he *HTTPError
+	if !errors.As(err, &he) || he.StatusCode != 400 {
+		t.Errorf("want HTTPError 400, get %v", err)
+	}
+}
+func TestBlankLines(t *testing.T) {
+	clean := ">a\nACGT\nAC\n>b\nGG\n"
+	tests := []string{
+		">a\nACGT\nAC\n>b\nGG\n\n\n\n",
+		">a\nACGT\nAC\n>b\nGG\n  \n\t\n \r\n",
+		"\n  \n>a\n\nACGT\n \t \nAC\n\n>b\n  \nGG\n",
+		">a\nACGT\nAC\n>b\nGG\n   ",
+		">a\nACGT\nAC\n>b\nGG\n\n \t",
+	}
+	want := ""
+	for _, s := range scanAll(strings.NewReader(clean)) {
+		want += s.String() + "\n"
+	}
+	for i, test := range tests {
+		get := ""
+		for _, s := range scanAll(strings.NewReader(test)) {
+			get += s.String() + "\n"
+		}
+		if get != want {
+			t.Errorf("%d: want:\n%s\nget:\n%s\n", i, want, get)
+		}
+		sc := NewScanner(strings.NewReader(test))
+		n := 0
+		for sc.ScanLine() {
+			if len(sc.Line()) == 0 {
+				t.Errorf("%d: empty line returned", i)
+			}
+			n += len(sc.Line())
+		}
+		if f := sc.Flush(); len(f) > 0 {
+			t.Errorf("%d: flushed %q", i, f)
+		}
+		if n != 12 {
+			t.Errorf("%d: want 12 bytes, get %d", i, n)
+		}
+	}
+}
+func TestRawHeaders(t *testing.T) {
+	in := ">  weird   spacing\nACGT\nAC\n> b \nGG\n"
+	for _, scanRaw := range []bool{false, true} {
+		for _, writeRaw := range []bool{false, true} {
+			opts := []ScannerOption{WithPreserveWrapping()}
+			if scanRaw {
+				opts = append(opts, WithRawHeaders())
+			}
+			sc := NewScanner(strings.NewReader(in), opts...)
+			var wopts []WriterOption
+			if writeRaw {
+				wopts = append(wopts, WithRawHeaderOutput())
+			}
+			var b bytes.Buffer
+			w := NewWriter(&b, KeepLineLength, wopts...)
+			var first *Sequence
+			for sc.ScanSequence() {
+				s := sc.Sequence()
+				if first == nil {
+					first = s
+				}
+				w.Write(s)
+			}
+			w.Flush()
+			same := b.String() == in
+			if same != (scanRaw && writeRaw) {
+				t.Errorf("scan %t, write %t: get:\n%s\n",
+					scanRaw, writeRaw, b.String())
+			}
+			if first.Header() != "weird   spacing" {
+				t.Errorf("header: %q", first.Header())
+			}
+			if scanRaw {
+				if first.RawHeader() != "  weird   spacing" {
+					t.Errorf("raw header: %q",
+						first.RawHeader())
+				}
+				first.SetHeader("x")
+				if first.RawHeader() != "" {
+					t.Errorf("raw header not cleared")
+				}
+			}
+		}
+	}
+}
+func TestCount(t *testing.T) {
+	s := NewSequence("s", []byte("ACGTSsNNacgtn"))
+	tests := []struct {
+		residues []byte
+		fold     bool
+		want     int
+	}{
+		{[]byte("N"), false, 2},
+		{[]byte("N"), true, 3},
+		{[]byte("AT"), false, 2},
+		{[]byte("at"), true, 4},
+		{[]byte("GCgcSs"), false, 6},
+		{[]byte("GCS"), true, 6},
+		{[]byte("X"), true, 0},
+		{nil, false, 0},
+	}
+	for _, test := range tests {
+		get := s.Count(test.residues...)
+		if test.fold {
+			get = s.CountFold(test.residues...)
+		}
+		if get != test.want {
+			t.Errorf("%q, fold %t: want %d, get %d",
+				test.residues, test.fold, test.want, get)
+		}
+	}
+	want := float64(s.Count('G', 'C', 'g', 'c', 'S', 's')) / 13
+	if get := s.GC(); get != want {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	if get := s.Fraction('N', 'n'); get != 3.0/13.0 {
+		t.Errorf("want:\n%v\nget:\n%v\n", 3.0/13.0, get)
+	}
+	e := NewSequence("e", nil)
+	if e.Fraction('A') != 0 || e.GC() != 0 {
+		t.Error("fraction of empty sequence isn't 0")
+	}
+}
+func TestPositions(t *testing.T) {
+	s := NewSequence("s", []byte("NACnGRTNy"))
+	e := NewSequence("e", nil)
+	tests := []struct {
+		get  []int
+		want []int
+	}{
+		{s.Positions('N'), []int{0, 7}},
+		{s.PositionsFold('N'), []int{0, 3, 7}},
+		{s.PositionsFold('n'), []int{0, 3, 7}},
+		{s.Positions('X'), nil},
+		{s.PositionsFold('-'), nil},
+		{s.PositionsOf(IsAmbiguous), []int{0, 3, 5, 7, 8}},
+		{e.Positions('N'), nil},
+		{e.PositionsFold('N'), nil},
+		{e.PositionsOf(IsAmbiguous), nil},
+	}
+	for i, test := range tests {
+		if !reflect.DeepEqual(test.get, test.want) {
+			t.Errorf("%d: want:\n%v\nget:\n%v\n", i, test.want,
+				test.get)
+		}
+	}
+}
+func TestReplace(t *testing.T) {
+	s := randomResidues(10000000)
+	c := s.Counts()
+	if get := s.Replace('A', 'U'); uint64(get) != c['A'] {
+		t.Errorf("Replace: want %d, get %d", c['A'], get)
+	}
+	if s.Count('A') != 0 || uint64(s.Count('U')) != c['A'] {
+		t.Error("Replace left residues")
+	}
+	want := c['n'] + c['N']
+	if get := s.ReplaceFold('n', '-'); uint64(get) != want {
+		t.Errorf("ReplaceFold: want %d, get %d", want, get)
+	}
+	if s.CountFold('N') != 0 || uint64(s.Count('-')) != want {
+		t.Error("ReplaceFold left residues")
+	}
+	r := NewSequence("r", []byte("ACGU.acgu-"))
+	if n := r.Replace('X', 'Y'); n != 0 {
+		t.Errorf("replaced %d absent residues", n)
+	}
+	r.ReplaceFunc(func(c byte) byte {
+		switch c {
+		case 'U':
+			return 'T'
+		case 'u':
+			return 't'
+		case '.':
+			return '-'
+		}
+		return c
+	})
+	if get := string(r.Data()); get != "ACGT-acgt-" {
+		t.Errorf("want:\nACGT-acgt-\nget:\n%s\n", get)
+	}
+}
+func TestExpandIUPAC(t *testing.T) {
+	s := NewSequence("p fwd", []byte("AcRyN"))
+	seqs, err := s.ExpandIUPAC(16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := ""
+	for _, r := range seqs {
+		get += r.Header() + " " + string(r.Data()) + "\n"
+	}
+	want := ""
+	k := 1
+	for _, r := range "AG" {
+		for _, y := range "ct" {
+			for _, n := range "ACGT" {
+				want += fmt.Sprintf("p_%d fwd Ac%c%c%c\n", k, r, y, n)
+				k++
+			}
+		}
+	}
+	if get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+	_, err = s.ExpandIUPAC(15)
+	if !errors.Is(err, ErrExpansionLimit) ||
+		!strings.Contains(err.Error(), "expands to 16 sequences") {
+		t.Errorf("want limit error with count 16, get %v", err)
+	}
+	s = NewSequence("n", bytes.Repeat([]byte("N"), 40))
+	_, err = s.ExpandIUPAC(1000)
+	if err == nil || !strings.Contains(err.Error(),
+		"1208925819614629174706176") {
+		t.Errorf("want count 4^40, get %v", err)
+	}
+	s = NewSequence("g", []byte("AC-T"))
+	if _, err = s.ExpandIUPAC(10); err == nil {
+		t.Error("gap expanded")
+	}
+}
+func TestCollapseToIUPAC(t *testing.T) {
+	for _, c := range []byte("ACGTRYSWKMBDHVN") {
+		e, err := NewSequence("c", []byte{c}).ExpandIUPAC(4)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := CollapseToIUPAC(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if get := string(s.Data()); get != string(c) {
+			t.Errorf("want:\n%c\nget:\n%s\n", c, get)
+		}
+	}
+	variants := []*Sequence{
+		NewSequence("v1", []byte("ACGTA")),
+		NewSequence("v2", []byte("acTTG")),
+		NewSequence("v3", []byte("GCGYA")),
+	}
+	s, err := CollapseToIUPAC(variants)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if get := string(s.Data()); get != "RCKYR" {
+		t.Errorf("want:\nRCKYR\nget:\n%s\n", get)
+	}
+	e, err := s.ExpandIUPAC(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := make(map[string]bool)
+	for _, x := range e {
+		found[string(x.Data())] = true
+	}
+	for _, v := range []string{"ACGTA", "ACTTG", "GCGCA", "GCGTA"} {
+		if !found[v] {
+			t.Errorf("%s missing from expansion", v)
+		}
+	}
+	variants = append(variants, NewSequence("short", []byte("AC")))
+	_, err = CollapseToIUPAC(variants)
+	if !errors.Is(err, ErrUnequalLengths) ||
+		!strings.Contains(err.Error(), "short") {
+		t.Errorf("want unequal lengths naming short, get %v", err)
+	}
+	variants[3] = NewSequence("gap", []byte("AC-TA"))
+	if _, err = CollapseToIUPAC(variants); err == nil {
+		t.Error("gap collapsed")
+	}
+}
+func TestAt(t *testing.T) {
+	s := NewSequence("contig_7", []byte("ACGT"))
+	for _, i := range []int{-1, 4, 100} {
+		_, err := s.At(i)
+		if !errors.Is(err, ErrOutOfRange) ||
+			!strings.Contains(err.Error(), "contig_7") {
+			t.Errorf("At(%d): want out of range, get %v", i, err)
+		}
+		if err = s.Set(i, 'N'); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("Set(%d): want out of range, get %v", i, err)
+		}
+	}
+	if err := s.Set(3, 'N'); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := s.At(3); err != nil || c != 'N' {
+		t.Errorf("want:\nN\nget:\n%c, %v\n", c, err)
+	}
+	if c := s.AtUnsafe(0); c != 'A' {
+		t.Errorf("want:\nA\nget:\n%c\n", c)
+	}
+	s.Freeze()
+	if err := s.Set(0, 'N'); !errors.Is(err, ErrFrozen) {
+		t.Errorf("want ErrFrozen, get %v", err)
+	}
+	if c, _ := s.At(0); c != 'A' {
+		t.Errorf("frozen residue changed to %c", c)
+	}
+}
+func TestCodons(t *testing.T) {
+	s := NewSequence("s", []byte("ATGCCgTA"))
+	list := func(it func(int, func(int, [3]byte) bool), frame int) string {
+		r := ""
+		it(frame, func(i int, c [3]byte) bool {
+			r += fmt.Sprintf("%d:%s ", i, c[:])
+			return true
+		})
+		return r
+	}
+	tests := []struct {
+		reverse bool
+		frame   int
+		want    string
+	}{
+		{false, 0, "0:ATG 3:CCg "},
+		{false, 1, "1:TGC 4:CgT "},
+		{false, 2, "2:GCC 5:gTA "},
+		{true, 0, "5:TAc 2:GGC "},
+		{true, 1, "4:AcG 1:GCA "},
+		{true, 2, "3:cGG 0:CAT "},
+	}
+	for _, test := range tests {
+		it := s.Codons
+		if test.reverse {
+			it = s.CodonsReverse
+		}
+		if get := list(it, test.frame); get != test.want {
+			t.Errorf("reverse %t, frame %d: want:\n%s\nget:\n%s\n",
+				test.reverse, test.frame, test.want, get)
+		}
+	}
+	r := s.ReverseComplemented()
+	n := r.Length()
+	for frame := 0; frame < 3; frame++ {
+		want := ""
+		r.Codons(frame, func(i int, c [3]byte) bool {
+			want += fmt.Sprintf("%d:%s ", n-i-3, c[:])
+			return true
+		})
+		if get := list(s.CodonsReverse, frame); get != want {
+			t.Errorf("frame %d: want:\n%s\nget:\n%s\n", frame,
+				want, get)
+		}
+	}
+	k := 0
+	s.Codons(0, func(int, [3]byte) bool {
+		k++
+		return false
+	})
+	if k != 1 {
+		t.Errorf("want 1 codon before stopping, get %d", k)
+	}
+	if get := list(NewSequence("e", []byte("AC")).Codons, 0); get != "" {
+		t.Errorf("codons in partial codon: %s", get)
+	}
+}
+func TestWindowsIter(t *testing.T) {
+	s := NewSequence("s", []byte("ACGTACGTAC"))
+	tests := []struct {
+		size, step int
+		partial    bool
+		want       string
+	}{
+		{4, 4, true, "0:ACGT 4:ACGT 8:AC "},
+		{4, 4, false, "0:ACGT 4:ACGT "},
+		{4, 3, true, "0:ACGT 3:TACG 6:GTAC "},
+		{4, 3, false, "0:ACGT 3:TACG 6:GTAC "},
+		{20, 5, true, "0:ACGTACGTAC "},
+		{20, 5, false, ""},
+	}
+	for _, test := range tests {
+		get := ""
+		s.WindowsIter(test.size, test.step, test.partial,
+			func(i int, d []byte) bool {
+				get += fmt.Sprintf("%d:%s ", i, d)
+				return true
+			})
+		if get != test.want {
+			t.Errorf("%d/%d/%t: want:\n%s\nget:\n%s\n", test.size,
+				test.step, test.partial, test.want, get)
+		}
+	}
+	var wins []*Sequence
+	s.WindowSequences(4, 3, true, func(i int, w *Sequence) bool {
+		wins = append(wins, w)
+		return true
+	})
+	want := Windows([]*Sequence{s}, 4, 3)
+	if len(wins) != len(want) {
+		t.Fatalf("want %d windows, get %d", len(want), len(wins))
+	}
+	for i, w := range wins {
+		if !w.Equals(want[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", want[i], w)
+		}
+	}
+	wins[0].Data()[0] = 'N'
+	if s.Data()[0] != 'A' {
+		t.Error("window copy aliases sequence")
+	}
+	n := 0
+	NewSequence("e", nil).WindowsIter(4, 4, true,
+		func(int, []byte) bool {
+			n++
+			return true
+		})
+	if n != 0 {
+		t.Errorf("empty sequence has %d windows", n)
+	}
+}
+func TestSoftMaskedSegments(t *testing.T) {
+	s := NewSequence("chr1 test", []byte("acgtACGTTaaAccccNNg"))
+	tests := []struct {
+		masked bool
+		minLen int
+		want   string
+	}{
+		{true, 1, ">chr1:0-4 test\nacgt\n>chr1:9-11 test\naa\n" +
+			">chr1:12-16 test\ncccc\n>chr1:18-19 test\ng\n"},
+		{true, 3, ">chr1:0-4 test\nacgt\n>chr1:12-16 test\ncccc\n"},
+		{false, 1, ">chr1:4-9 test\nACGTT\n>chr1:11-12 test\nA\n" +
+			">chr1:16-18 test\nNN\n"},
+		{false, 3, ">chr1:4-9 test\nACGTT\n"},
+		{true, 10, ""},
+	}
+	for _, test := range tests {
+		segs := s.UnmaskedSegments(test.minLen)
+		if test.masked {
+			segs = s.SoftMaskedSegments(test.minLen)
+		}
+		get := ""
+		for _, seg := range segs {
+			get += seg.String() + "\n"
+		}
+		if get != test.want {
+			t.Errorf("masked %t, min %d: want:\n%s\nget:\n%s\n",
+				test.masked, test.minLen, test.want, get)
+		}
+	}
+}
+func TestLowercase(t *testing.T) {
+	s := NewSequence("s", []byte("acgtNN--ACGT**nx"))
+	if get := s.LowercaseCount(); get != 6 {
+		t.Errorf("want:\n6\nget:\n%d\n", get)
+	}
+	if get := s.LowercaseFraction(); get != 6.0/16.0 {
+		t.Errorf("want:\n%v\nget:\n%v\n", 6.0/16.0, get)
+	}
+	e := NewSequence("e", nil)
+	if e.LowercaseCount() != 0 || e.LowercaseFraction() != 0 {
+		t.Error("lowercase in empty sequence")
+	}
+	st := Stats([]*Sequence{s, e, NewSequence("u", []byte("aC"))})
+	if st.LowercaseCount != 7 {
+		t.Errorf("want:\n7\nget:\n%d\n", st.LowercaseCount)
+	}
+}
+func TestWriteTracks(t *testing.T) {
+	seqs := []*Sequence{
+		NewSequence("c1 first", []byte("GGAAGCATTT")),
+		NewSequence("c2", []byte("GCG")),
+	}
+	gc := func(s *Sequence) float64 { return s.GC() }
+	tests := []struct {
+		window, step int
+		bedGraph     string
+		wiggle       string
+	}{
+		{4, 4,
+			"c1\t0\t4\t0.5000\nc1\t4\t8\t0.5000\n" +
+				"c1\t8\t10\t0.0000\nc2\t0\t3\t1.0000\n",
+			"fixedStep chrom=c1 start=1 step=4 span=4\n" +
+				"0.5000\n0.5000\n" +
+				"fixedStep chrom=c1 start=9 step=4 span=2\n" +
+				"0.0000\n" +
+				"fixedStep chrom=c2 start=1 step=4 span=3\n" +
+				"1.0000\n"},
+		{4, 3,
+			"c1\t0\t3\t0.5000\nc1\t3\t6\t0.5000\n" +
+				"c1\t6\t10\t0.0000\nc2\t0\t3\t1.0000\n",
+			"fixedStep chrom=c1 start=1 step=3 span=3\n" +
+				"0.5000\n0.5000\n" +
+				"fixedStep chrom=c1 start=7 step=3 span=4\n" +
+				"0.0000\n" +
+				"fixedStep chrom=c2 start=1 step=3 span=3\n" +
+				"1.0000\n"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		err := WriteBedGraph(&b, seqs, test.window, test.step, gc)
+		want := "track type=bedGraph\n" + test.bedGraph
+		if err != nil || b.String() != want {
+			t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+		}
+		b.Reset()
+		err = WriteWiggle(&b, seqs, test.window, test.step, gc)
+		want = "track type=wiggle_0\n" + test.wiggle
+		if err != nil || b.String() != want {
+			t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+		}
+	}
+}
+func TestManifest(t *testing.T) {
+	orig := ">a one\nACGTAC\nGT\n>b\nTTTT\n>c\nGG\n"
+	var m bytes.Buffer
+	if err := WriteManifest(&m, strings.NewReader(orig)); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("a\t8\t%x\nb\t4\t%x\nc\t2\t%x\n",
+		md5.Sum([]byte("ACGTACGT")), md5.Sum([]byte("TTTT")),
+		md5.Sum([]byte("GG")))
+	if m.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, m.String())
+	}
+	same := ">a two\nacg-TA\nCG.T\n>b\nTT\nTT\n>c edited\nGG\n"
+	rep, err := VerifyManifest(strings.NewReader(same),
+		strings.NewReader(m.String()))
+	if err != nil || !rep.OK() || rep.Matched != 3 {
+		t.Errorf("want match, get %+v, %v", rep, err)
+	}
+	diff := ">a\nACGTACGT\n>c\nGC\n>d\nA\n"
+	rep, err = VerifyManifest(strings.NewReader(diff),
+		strings.NewReader(m.String()))
+	wantRep := VerifyReport{Missing: []string{"b"},
+		Extra: []string{"d"}, Mismatched: []string{"c"},
+		Matched: 1}
+	if err != nil || !reflect.DeepEqual(rep, wantRep) {
+		t.Errorf("want:\n%+v\nget:\n%+v, %v\n", wantRep, rep, err)
+	}
+	_, err = VerifyManifest(strings.NewReader(orig),
+		strings.NewReader("a\tx\tff\n"))
+	if err == nil {
+		t.Error("malformed manifest accepted")
+	}
+}
+func TestVerifyIndex(t *testing.T) {
+	orig := ">a\nACGT\nAC\n>b\nacgt\n"
+	entries, err := BuildFai(strings.NewReader(orig))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fai bytes.Buffer
+	WriteFai(&fai, entries)
+	tests := []struct {
+		fasta string
+		want  []string
+	}{
+		{orig, nil},
+		{">a\nAC\nGT\nAC\n>b\nacgt\n", []string{
+			`"a" has lines of 2/3 bytes, index says 4/5`,
+			`"b" starts at offset 15, index says 14`}},
+		{">a\nACGT\n>c\nACGT\n", []string{
+			`"a" has length 4, index says 6`,
+			`"c" not in index`, `"b" not in FASTA`}},
+	}
+	for i, test := range tests {
+		err := VerifyIndex(strings.NewReader(test.fasta),
+			bytes.NewReader(fai.Bytes()))
+		checkStale(t, i, err, test.want)
+	}
+	dict := "@HD\tVN:1.6\n" +
+		fmt.Sprintf("@SQ\tSN:a\tLN:6\tM5:%x\tUR:file:x.fa\n",
+			md5.Sum([]byte("ACGTAC"))) +
+		fmt.Sprintf("@SQ\tSN:b\tLN:4\tM5:%X\n", md5.Sum([]byte("ACGT")))
+	tests = []struct {
+		fasta string
+		want  []string
+	}{
+		{orig, nil},
+		{">a\nACG\nTAC\n>b\nACGT\n", nil},
+		{">a\nACGTAA\n>c\nA\n", []string{
+			fmt.Sprintf(`"a" has MD5 %x, dictionary says %x`,
+				md5.Sum([]byte("ACGTAA")),
+				md5.Sum([]byte("ACGTAC"))),
+			`"c" not in dictionary`, `"b" not in FASTA`}},
+	}
+	for i, test := range tests {
+		err := VerifyDict(strings.NewReader(test.fasta),
+			strings.NewReader(dict))
+		checkStale(t, i, err, test.want)
+	}
+}
+func checkStale(t *testing.T, i int, err error, want []string) {
+	if want == nil {
+		if err != nil {
+			t.Errorf("%d: unexpected error %v", i, err)
+		}
+		return
+	}
+	w := ErrStaleIndex.Error() + ": " + strings.Join(want, "; ")
+	if !errors.Is(err, ErrStaleIndex) || err.Error() != w {
+		t.Errorf("%d: want:\n%s\nget:\n%v\n", i, w, err)
+	}
+}
+func TestRewrap(t *testing.T) {
+	in := ">a  first \r\nAC GT\r\nA\n\nCGTA\n>empty\n>b\nTT\tTT"
+	tests := []struct {
+		width int
+		want  string
+	}{
+		{3, ">a  first \nACG\nTAC\nGTA\n>empty\n>b\nTTT\nT\n"},
+		{0, ">a  first \nACGTACGTA\n>empty\n>b\nTTTT\n"},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		err := Rewrap(strings.NewReader(in), &b, test.width)
+		if err != nil || b.String() != test.want {
+			t.Errorf("want:\n%s\nget:\n%s\n%v", test.want,
+				b.String(), err)
+		}
+	}
+	s := randomResidues(100000)
+	var want, get bytes.Buffer
+	Format(&want, s, 60)
+	err := Rewrap(strings.NewReader(">s\n"+string(s.Data())+"\n"),
+		&get, 60)
+	if err != nil || get.String() != want.String() {
+		t.Errorf("rewrapping long line failed: %v", err)
+	}
+	err = Rewrap(strings.NewReader("AC\n>a\nGT\n"), &get, 60)
+	if !errors.Is(err, ErrNoHeader) {
+		t.Errorf("want ErrNoHeader, get %v", err)
+	}
+}
+func TestReverseComplementStream(t *testing.T) {
+	in := ">a one\nAACG\nTTG\n>e\n>b\nACGTNacgtn\n"
+	want := ">a one RC\nCAAC\nGTT\n>e RC\n>b RC\nnacgtNACGT\n"
+	var b bytes.Buffer
+	err := ReverseComplementStream(strings.NewReader(in), &b, " RC")
+	if err != nil || b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n%v", want, b.String(), err)
+	}
+	var m bytes.Buffer
+	for _, s := range scanAll(strings.NewReader(in)) {
+		s.ReverseComplement()
+		s.AppendToHeader(" RC")
+		Format(&m, s, NoWrap)
+	}
+	b.Reset()
+	ReverseComplementStream(strings.NewReader(in), &b, " RC")
+	var r bytes.Buffer
+	Rewrap(&b, &r, NoWrap)
+	if r.String() != m.String() {
+		t.Errorf("want:\n%s\nget:\n%s\n", m.String(), r.String())
+	}
+}
+func TestExtractOne(t *testing.T) {
+	in := ">chr1 x\nACGT\nAC\n>chrM\nGGCC\nGG\n>chr2\nTTTT"
+	tests := []struct {
+		id, want string
+		found    bool
+	}{
+		{"chr1", ">chr1 x\nACGT\nAC\n", true},
+		{"chrM", ">chrM\nGGCC\nGG\n", true},
+		{"chr2", ">chr2\nTTTT", true},
+		{"chrX", "", false},
+	}
+	for _, test := range tests {
+		var b bytes.Buffer
+		found, err := ExtractOne(strings.NewReader(in), test.id, &b)
+		if err != nil || found != test.found ||
+			b.String() != test.want {
+			t.Errorf("%s: want:\n%s\nget:\n%s\n%v", test.id,
+				test.want, b.String(), err)
+		}
+	}
+	r := io.MultiReader(strings.NewReader(in[:31]),
+		iotest.ErrReader(errors.New("read too far")))
+	s, err := ExtractOneSeq(r, "chrM")
+	if err != nil || s.String() != ">chrM\nGGCCGG" {
+		t.Errorf("want:\n>chrM\nGGCCGG\nget:\n%v\n%v", s, err)
+	}
+	_, err = ExtractOneSeq(strings.NewReader(in), "chrX")
+	if !errors.Is(err, ErrUnknownSequence) {
+		t.Errorf("want ErrUnknownSequence, get %v", err)
+	}
+	in = ">a\nAC\n>chrM"
+	var b bytes.Buffer
+	found, err := ExtractOne(strings.NewReader(in), "chrM", &b)
+	if err != nil || !found || b.String() != ">chrM" {
+		t.Errorf("final header: want:\n>chrM\nget:\n%s\n%v %v",
+			b.String(), found, err)
+	}
+	s, err = ExtractOneSeq(strings.NewReader(in), "chrM")
+	if err != nil || s.Header() != "chrM" || s.Length() != 0 {
+		t.Errorf("final header: want empty chrM, get %v %v", s, err)
+	}
+}
+func TestParseRegion(t *testing.T) {
+	tests := []struct {
+		region     string
+		name       string
+		start, end int
+		err        bool
+	}{
+		{"chr1:1,000-2,000", "chr1", 999, 2000, false},
+		{"chr1", "chr1", 0, -1, false},
+		{"chr1:1000-", "chr1", 999, -1, false},
+		{"chr1:1000", "chr1", 999, -1, false},
+		{"chr1:5-5", "chr1", 4, 5, false},
+		{"HLA-DRB1*13:01:01", "HLA-DRB1*13:01", 0, -1, false},
+		{"HLA-DRB1*13:01:01:2-4", "HLA-DRB1*13:01:01", 1, 4, false},
+		{"chr1:x-y", "chr1:x-y", 0, -1, false},
+		{"chr1:0-5", "chr1:0-5", 0, -1, false},
+		{":1-5", ":1-5", 0, -1, false},
+		{"", "", 0, 0, true},
+	}
+	for _, test := range tests {
+		name, start, end, err := ParseRegion(test.region)
+		if (err != nil) != test.err || err == nil &&
+			(name != test.name || start != test.start ||
+				end != test.end) {
+			t.Errorf("%q: want %q %d %d, get %q %d %d %v",
+				test.region, test.name, test.start, test.end,
+				name, start, end, err)
+		}
+	}
+	in := ">HLA-DRB1*13:01\nACGTACGT\n>HLA-DRB1*13:01:01\nGGGGCC\n"
+	entries, _ := BuildFai(strings.NewReader(in))
+	fx := NewFaidx(strings.NewReader(in), entries)
+	fetches := []struct{ region, want string }{
+		{"HLA-DRB1*13:01:01", "GGGGCC"},
+		{"HLA-DRB1*13:01:01:5-6", "CC"},
+		{"HLA-DRB1*13:01:3-4", "GT"},
+		{"HLA-DRB1*13:01:1,000", ""},
+		{"HLA-DRB1*13:02", ""},
+	}
+	for _, f := range fetches {
+		s, err := fx.FetchRegion(f.region)
+		if f.want == "" {
+			if err == nil {
+				t.Errorf("%q: want error", f.region)
+			}
+			continue
+		}
+		if err != nil || string(s.Data()) != f.want {
+			t.Errorf("%q: want %s, get %v %v", f.region, f.want,
+				s, err)
+		}
+	}
+}
+
+type offsetRecorder struct {
+	r    io.ReaderAt
+	offs []int64
+}
+
+func (o *offsetRecorder) ReadAt(p []byte, off int64) (int, error) {
+	o.offs = append(o.offs, off)
+	return o.r.ReadAt(p, off)
+}
+func TestFetchMany(t *testing.T) {
+	in := ">a\nACGTACGT\n>b\nGGGGCCCC\n"
+	entries, _ := BuildFai(strings.NewReader(in))
+	rec := &offsetRecorder{r: strings.NewReader(in)}
+	fx := NewFaidx(rec, entries)
+	regions := []string{"b:5-8", "a:2-3", "x:1-2", "b:1-2", "a:7-20",
+		"a"}
+	seqs, err := fx.FetchMany(regions)
+	want := []string{"CCCC", "CG", "", "GG", "", "ACGTACGT"}
+	for i, s := range seqs {
+		get := ""
+		if s != nil {
+			get = string(s.Data())
+		}
+		if get != want[i] {
+			t.Errorf("%s: want %q, get %q", regions[i], want[i], get)
+		}
+	}
+	var fe FetchManyError
+	if !errors.As(err, &fe) || len(fe) != 2 || fe[0].Index != 2 ||
+		fe[1].Index != 4 {
+		t.Errorf("want errors for regions 2 and 4, get %v", err)
+	}
+	if !sort.SliceIsSorted(rec.offs, func(i, j int) bool {
+		return rec.offs[i] < rec.offs[j]
+	}) || len(rec.offs) != 4 {
+		t.Errorf("offsets not ascending: %v", rec.offs)
+	}
+}
+func TestFaidxExtractBED(t *testing.T) {
+	in := ">chr1 x\nAACCG\nGTT\n>chr2\nACGT\n"
+	entries, _ := BuildFai(strings.NewReader(in))
+	fx := NewFaidx(strings.NewReader(in), entries)
+	bed := "track name=test\nchr1\t0\t3\tfirst\t0\t+\n" +
+		"chr1\t4\t8\t.\t0\t-\nchr2\t2\t6\n"
+	_, err := fx.ExtractBED(strings.NewReader(bed))
+	if err == nil || !strings.Contains(err.Error(), "bed line 4") {
+		t.Errorf("want error on bed line 4, get %v", err)
+	}
+	out, err := fx.ExtractBEDClamped(strings.NewReader(bed))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := ""
+	for _, s := range out {
+		get += s.String() + "\n"
+	}
+	want := ">first\nAAC\n>chr1:4-8\nAACC\n>chr2:2-6\nGT\n"
+	if get != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, get)
+	}
+	_, err = fx.ExtractBED(strings.NewReader("chr3\t0\t1\n"))
+	if !errors.Is(err, ErrUnknownSequence) ||
+		!strings.Contains(err.Error(), "bed line 1") {
+		t.Errorf("want unknown sequence on bed line 1, get %v", err)
+	}
+}
+func TestLengths(t *testing.T) {
+	in := ">chr1 desc\r\nAC GT\r\n\nA\n>chrE\n>chr2\nGGGG\nGG\n>last"
+	var b bytes.Buffer
+	if err := WriteChromSizes(&b, strings.NewReader(in)); err != nil {
+		t.Fatal(err)
+	}
+	want := "chr1\t5\nchrE\t0\nchr2\t6\nlast\t0\n"
+	if b.String() != want {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, b.String())
+	}
+	f, err := os.Open("data/seq8.fasta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	lens, err := Lengths(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Seek(0, io.SeekStart)
+	seqs := scanAll(f)
+	if len(lens) != len(seqs) {
+		t.Fatalf("want %d lengths, get %d", len(seqs), len(lens))
+	}
+	for i, s := range seqs {
+		if lens[i] != (SeqLength{s.ID(), s.Length()}) {
+			t.Errorf("want %s %d, get %v", s.ID(), s.Length(),
+				lens[i])
+		}
+	}
+	if _, err := Lengths(strings.NewReader("AC\n>a\n")); !errors.Is(err,
+		ErrNoHeader) {
+		t.Errorf("want ErrNoHeader, get %v", err)
+	}
+}
+func TestCountRecords(t *testing.T) {
+	for i := 1; i <= 10; i++ {
+		name := fmt.Sprintf("data/seq%d.fasta", i)
+		d, err := ioutil.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := len(scanAll(bytes.NewReader(d)))
+		get, err := CountRecords(bytes.NewReader(d))
+		if err != nil || get != want {
+			t.Errorf("%s: want %d, get %d, %v", name, want, get, err)
+		}
+	}
+	in := ">a\nAC>GT\n>b desc > x\nA\n\n>c"
+	get, err := CountRecords(iotest.OneByteReader(
+		strings.NewReader(in)))
+	if err != nil || get != 3 {
+		t.Errorf("want 3, get %d, %v", get, err)
+	}
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(in))
+	zw.Close()
+	if get, err = CountRecords(&gz); err != nil || get != 3 {
+		t.Errorf("gzip: want 3, get %d, %v", get, err)
+	}
+}
+func TestHash64(t *testing.T) {
+	muts := map[string]func(s *Sequence){
+		"SetData":    func(s *Sequence) { s.SetData([]byte("AC")) },
+		"Shuffle":    func(s *Sequence) { s.Shuffle(SeededRand(1)) },
+		"Reverse":    func(s *Sequence) { s.Reverse() },
+		"Complement": func(s *Sequence) { s.Complement() },
+		"ReverseComplement": func(s *Sequence) {
+			s.ReverseComplement()
+		},
+		"ComplementParallel": func(s *Sequence) {
+			s.ComplementParallel(2)
+		},
+		"ReverseComplementParallel": func(s *Sequence) {
+			s.ReverseComplementParallel(2)
+		},
+		"Recycle":     func(s *Sequence) { Recycle(s) },
+		"Replace":     func(s *Sequence) { s.Replace('A', 'T') },
+		"ReplaceFold": func(s *Sequence) { s.ReplaceFold('a', 'T') },
+		"ReplaceFunc": func(s *Sequence) {
+			s.ReplaceFunc(func(c byte) byte { return c | 0x20 })
+		},
+		"Set": func(s *Sequence) { s.Set(0, 'N') },
+		"MaskBED": func(s *Sequence) {
+			MaskBED([]*Sequence{s}, strings.NewReader("s\t0\t5\n"),
+				false)
+		},
+	}
+	for name, f := range muts {
+		s := NewSequence("s", []byte("ACGTTGCAAC"))
+		h := s.Hash64()
+		f(s)
+		get := s.Hash64()
+		if get == h || get != hashData(s.data, false) {
+			t.Errorf("%s: hash %x before, %x after", name, h, get)
+		}
+	}
+	q, _ := NewQualSequence("q", []byte("ACGT"), []byte("III#"))
+	h := q.Hash64()
+	q.TrimQuality(20)
+	if get := q.Hash64(); get == h || get != hashData(q.data, false) {
+		t.Errorf("TrimQuality: hash %x before, %x after", h, get)
+	}
+	a := NewSequence("a", []byte("ACGT"))
+	b := NewSequence("b", []byte("ACGA"))
+	a.Hash64()
+	b.Hash64()
+	if a.EqualData(b) || a.Equals(b) {
+		t.Error("different data compared equal")
+	}
+	b.Set(3, 'T')
+	if !a.EqualData(b) || a.Equals(b) {
+		t.Error("EqualData failed after Set")
+	}
+	c := a.Clone()
+	c.Freeze()
+	if !c.Equals(a) || c.Hash64() != a.Hash64() {
+		t.Error("clone differs from original")
+	}
+	x := NewSequence("x", []byte("ACGT"))
+	y := NewSequence("x", []byte("TCGT"))
+	x.Hash64()
+	y.Hash64()
+	x.Data()[0] = 'T'
+	if !x.Equals(y) || !x.EqualData(y) {
+		t.Error("stale hash after write through Data")
+	}
+	var n *Sequence
+	if a.EqualData(n) || !n.EqualData(nil) {
+		t.Error("EqualData mishandles nil")
+	}
+}
+func TestView(t *testing.T) {
+	s := NewSequence("chr1 desc", []byte("ACGTTGCAacgsNN"))
+	s.SetLineLength(4)
+	v, err := s.View(2, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := v.Materialize()
+	if m.Header() != "chr1:2-10 desc" ||
+		string(m.Data()) != "GTTGCAac" {
+		t.Errorf("want:\nchr1:2-10 desc GTTGCAac\nget:\n%s %s\n",
+			m.Header(), m.Data())
+	}
+	if v.Length() != m.Length() || v.GC() != m.GC() ||
+		v.Count('T', 'c') != m.Count('T', 'c') {
+		t.Errorf("view %d %g %d, copy %d %g %d", v.Length(), v.GC(),
+			v.Count('T', 'c'), m.Length(), m.GC(), m.Count('T', 'c'))
+	}
+	var buf bytes.Buffer
+	n, err := v.WriteTo(&buf)
+	want := ">chr1:2-10 desc\nGTTG\nCAac\n"
+	if err != nil || buf.String() != want || n != int64(len(want)) {
+		t.Errorf("want:\n%s\nget:\n%s\n", want, buf.String())
+	}
+	if c, err := v.At(7); err != nil || c != 'c' {
+		t.Errorf("want c, get %c, %v", c, err)
+	}
+	if _, err := v.At(8); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("want ErrOutOfRange, get %v", err)
+	}
+	for _, r := range [][2]int{{-1, 2}, {3, 2}, {0, 15}} {
+		if _, err := s.View(r[0], r[1]); !errors.Is(err,
+			ErrOutOfRange) {
+			t.Errorf("%v: want ErrOutOfRange, get %v", r, err)
+		}
+	}
+	s.Replace('G', 'C')
+	if string(v.Materialize().Data()) != "CTTCCAac" ||
+		string(m.Data()) != "GTTGCAac" {
+		t.Errorf("view %s, copy %s", v.Materialize().Data(), m.Data())
+	}
+}
+func TestKeepDescription(t *testing.T) {
+	tests := []struct {
+		name, want string
+		f          func(s *Sequence) string
+	}{
+		{"RenameFromMap", "new the desc", func(s *Sequence) string {
+			RenameFromMap([]*Sequence{s},
+				strings.NewReader("old\tnew\n"), true)
+			return s.Header()
+		}},
+		{"ExpandIUPAC", "old_2 the desc", func(s *Sequence) string {
+			seqs, _ := s.ExpandIUPAC(2)
+			return seqs[1].Header()
+		}},
+		{"MetaFromHeader", "old the desc", func(s *Sequence) string {
+			s.AppendToHeader(" k=v")
+			s.MetaFromHeader()
+			return s.Header()
+		}},
+		{"WindowSequences", "old:3-6 the desc",
+			func(s *Sequence) string {
+				h := ""
+				s.WindowSequences(3, 3, false,
+					func(i int, w *Sequence) bool {
+						h = w.Header()
+						return i < 3
+					})
+				return h
+			}},
+		{"Translate", "old the desc", func(s *Sequence) string {
+			p, _ := s.Translate(1)
+			return p.Header()
+		}},
+	}
+	for _, test := range tests {
+		s := NewSequence("old the desc", []byte("ATGRCCTAA"))
+		if get := test.f(s); get != test.want {
+			t.Errorf("%s: want:\n%s\nget:\n%s\n", test.name,
+				test.want, get)
+		}
+	}
+}
+func TestNoWrapPaths(t *testing.T) {
+	want := ">s\nACGTACGT\n"
+	for _, l := range []int{NoWrap, -1} {
+		s := NewSequence("s", []byte("ACGTACGT"))
+		s.SetLineLength(l)
+		if s.LineLength() != NoWrap {
+			t.Errorf("SetLineLength(%d): want line length %d, get %d",
+				l, NoWrap, s.LineLength())
+		}
+		outs := make(map[string]string)
+		outs["String"] = s.String() + "\n"
+		var b1, b2, b3, b4, b5 bytes.Buffer
+		Format(&b1, s, KeepLineLength)
+		outs["Format"] = b1.String()
+		w := NewWriter(&b2, KeepLineLength)
+		w.Write(s)
+		w.Flush()
+		outs["Writer"] = b2.String()
+		v, _ := s.View(0, s.Length())
+		v.WriteTo(&b3)
+		outs["View"] = strings.Replace(b3.String(), ":0-8", "", 1)
+		r := NewFastaReader(s)
+		io.Copy(&b4, r)
+		outs["NewFastaReader"] = b4.String()
+		Rewrap(strings.NewReader(">s\nACGT\nACGT\n"), &b5, NoWrap)
+		outs["Rewrap"] = b5.String()
+		for name, get := range outs {
+			if get != want {
+				t.Errorf("%s with %d: want:\n%s\nget:\n%s\n", name, l,
+					want, get)
+			}
+		}
+	}
+	s := NewSequence("s", []byte("ACGT"))
+	q, _ := NewQualSequence("q", []byte("AC"), []byte("II"))
+	var b bytes.Buffer
+	errs := map[string]error{
+		"Format":    Format(&b, s, -1),
+		"Format -2": Format(&b, s, -2),
+		"Writer":    NewWriter(&b, -1).Write(s),
+		"Writer -2": NewWriter(&b, -2).Write(s),
+		"Rewrap":    Rewrap(strings.NewReader(">s\nA\n"), &b, -1),
+		"WriteQual": WriteQual(&b, []*QualSequence{q}, -1),
+	}
+	for name, err := range errs {
+		if !errors.Is(err, ErrLineLength) {
+			t.Errorf("%s: want ErrLineLength, get %v", name, err)
+		}
+	}
+	if b.Len() > 0 {
+		t.Errorf("want no output, get %q", b.String())
+	}
+}
+func TestIrregularities(t *testing.T) {
+	in := " \n>a desc \nACGT\nAC\nACGT\n\n>b\r\nACG \r\nACGT\r\n" +
+		">c\nAC GT\nACGT\nAC"
+	plain := scanAll(strings.NewReader(in))
+	sc := NewScanner(strings.NewReader(in), WithIrregularities())
+	var seqs []*Sequence
+	for sc.ScanSequence() {
+		seqs = append(seqs, sc.Sequence())
+	}
+	if len(seqs) != len(plain) {
+		t.Fatalf("want %d sequences, get %d", len(plain), len(seqs))
+	}
+	for i, s := range seqs {
+		if !s.Equals(plain[i]) {
+			t.Errorf("want:\n%s\nget:\n%s\n", plain[i], s)
+		}
+	}
+	want := []Irregularity{
+		{BlankLine, 1, ""},
+		{TrailingWhitespace, 2, "a"},
+		{InconsistentWidth, 5, "a"},
+		{BlankLine, 6, "a"},
+		{MixedLineEndings, 7, "b"},
+		{TrailingWhitespace, 8, "b"},
+		{InconsistentWidth, 9, "b"},
+	}
+	get := sc.Irregularities()
+	if !reflect.DeepEqual(get, want) {
+		t.Errorf("want:\n%v\nget:\n%v\n", want, get)
+	}
+	if BlankLine.String() != "blank line" {
+		t.Errorf("want blank line, get %s", BlankLine)
+	}
+}
+func TestLint(t *testing.T) {
+	in := ">a\nACGT\nAC\nAC\n>b\n>a x\nAC1T\nac1#\n\n>c \nNNNN"
+	rep, err := Lint(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []LintFinding{
+		{SeverityWarning, "missing-final-newline", 0, "",
+			"input doesn't end in a newline"},
+		{SeverityWarning, "inconsistent-width", 4, "a",
+			"inconsistent width"},
+		{SeverityWarning, "empty-record", 5, "b",
+			"record without residues"},
+		{SeverityError, "duplicate-id", 6, "a",
+			"ID first used on line 1"},
+		{SeverityInfo, "mixed-case", 6, "a",
+			"record contains upper and lower case residues"},
+		{SeverityError, "non-iupac", 7, "a",
+			"character '1' occurs 2 times, first at position 3"},
+		{SeverityError, "non-iupac", 8, "a",
+			"character '#' occurs 1 times, first at position 8"},
+		{SeverityWarning, "blank-line", 9, "a", "blank line"},
+		{SeverityInfo, "trailing-whitespace", 10, "c",
+			"trailing whitespace"},
+	}
+	if rep.Records != 4 || !reflect.DeepEqual(rep.Findings, want) {
+		t.Errorf("want:\n%v\nget:\n%d %v\n", want, rep.Records,
+			rep.Findings)
+	}
+	if rep.OK() {
+		t.Error("report with errors is OK")
+	}
+	fq := "@r\nACGT\n+\nIIII\n"
+	rep, _ = Lint(strings.NewReader(fq))
+	if len(rep.Findings) == 0 || rep.Findings[0].Check != "fastq-like" {
+		t.Errorf("FASTQ: get %v", rep.Findings)
+	}
+	long := ">l\n" + strings.Repeat("A", 1<<20+1) + "\n"
+	rep, _ = Lint(strings.NewReader(long))
+	if len(rep.Findings) != 1 || rep.Findings[0].Check != "long-line" {
+		t.Errorf("long line: get %v", rep.Findings)
+	}
+	rep, _ = Lint(strings.NewReader(">s\nACGT\n>t\nacgt\n"))
+	if !rep.OK() || len(rep.Findings) != 0 {
+		t.Errorf("clean input: get %v", rep.Findings)
+	}
+	rep, _ = Lint(strings.NewReader(">s\nAC\n>s\nAC\n\n"))
+	var b bytes.Buffer
+	rep.Write(&b)
+	wantText := "line 3: s: error: duplicate-id: ID first used on " +
+		"line 1\nline 5: s: warning: blank-line: blank line\n" +
+		"2 records, 1 errors, 1 warnings, 0 notes\n"
+	if b.String() != wantText {
+		t.Errorf("want:\n%s\nget:\n%s\n", wantText, b.String())
+	}
+	b.Reset()
+	rep.WriteJSON(&b)
+	var dec struct {
+		Records  int
+		Findings []map[string]interface{}
+	}
+	if err := json.Unmarshal(b.Bytes(), &dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Records != 2 || len(dec.Findings) != 2 ||
+		dec.Findings[0]["severity"] != "error" ||
+		dec.Findings[1]["line"] != 5.0 {
+		t.Errorf("unexpected JSON:\n%s", b.String())
+	}
+}
+func TestFormatVerbs(t *testing.T) {
+	s := NewSequence("s1 \"a\" desc", []byte("ACGTACGTACGTAC"))
+	s.SetLineLength(6)
+	short := NewSequence("s2", []byte("ACG"))
+	var null *Sequence
+	tests := []struct {
+		format string
+		arg    interface{}
+		want   string
+	}{
+		{"%s", s, ">s1 \"a\" desc\nACGTAC\nGTACGT\nAC"},
+		{"%.8s", s, ">s1 \"a\" desc\nACGTAC\nGT…"},
+		{"%.20s", s, ">s1 \"a\" desc\nACGTAC\nGTACGT\nAC"},
+		{"%v", s, "s1 (14 bp) ACGTACGTAC…"},
+		{"%.4v", s, "s1 (14 bp) ACGT…"},
+		{"%v", short, "s2 (3 bp) ACG"},
+		{"%v", []*Sequence{short, short}, "[s2 (3 bp) ACG s2 (3 bp) ACG]"},
+		{"%q", s, `"s1 \"a\" desc"`},
+		{"%d", short, "%!d(*fasta.Sequence=s2)"},
+		{"%v", null, "<nil>"},
+	}
+	for _, test := range tests {
+		get := fmt.Sprintf(test.format, test.arg)
+		if get != test.want {
+			t.Errorf("%s: want:\n%s\nget:\n%s\n", test.format,
+				test.want, get)
+		}
+	}
+}
+func TestVerifyAlignment(t *testing.T) {
+	aln := func(d ...string) []*Sequence {
+		var seqs []*Sequence
+		for i, x := range d {
+			h := fmt.Sprintf("s%d", i+1)
+			seqs = append(seqs, NewSequence(h, []byte(x)))
+		}
+		return seqs
+	}
+	tests := []struct {
+		seqs    []*Sequence
+		want    string
+		unequal bool
+	}{
+		{aln("AC-T", "ACGT", "A--T"), "", false},
+		{nil, "not an alignment: no sequences", false},
+		{aln("ACG", "ACGT", "ACGT", "AC"), "not an alignment: " +
+			`"s1" has length 3 instead of 4; ` +
+			`"s4" has length 2 instead of 4`, true},
+		{aln("AC-T", "AC.T", "A..-"), "not an alignment: " +
+			`"s2" uses gap '.' instead of '-'; ` +
+			`"s3" uses gap '.' instead of '-'`, false},
+	}
+	for i, test := range tests {
+		err := VerifyAlignment(test.seqs)
+		get := ""
+		if err != nil {
+			get = err.Error()
+		}
+		if get != test.want {
+			t.Errorf("%d: want:\n%s\nget:\n%s\n", i, test.want, get)
+		}
+		if errors.Is(err, ErrUnequalLengths) != test.unequal {
+			t.Errorf("%d: want unequal lengths %t", i, test.unequal)
+		}
+	}
+}
+func TestVerifyPairs(t *testing.T) {
+	in1 := ">a/1\nAC\n>b\nAC\n>c 1:N:0:ACGT\nAC\n>d\nAC\n>e\nAC\n"
+	in2 := ">a/2\nGT\n>b\nGT\n>c 2:N:0:ACGT\nGT\n>e\nGT\n"
+	rep, err := VerifyPairs(strings.NewReader(in1),
+		strings.NewReader(in2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := PairReport{Records1: 5, Records2: 4, Pairs: 4,
+		Identical: 1, Slash: 1, Casava: 1, Mismatches: 1,
+		FirstMismatch: 3, MismatchHeaders: [2]string{"d", "e"}}
+	if rep != want {
+		t.Errorf("want:\n%+v\nget:\n%+v\n", want, rep)
+	}
+	if rep.OK() {
+		t.Error("broken pairs are OK")
+	}
+	tests := []struct {
+		h1, h2 string
+		want   mateNaming
+	}{
+		{"r/1", "r/1", styleMismatch},
+		{"r/2", "r/1", styleMismatch},
+		{"r 1:N", "r 1:N", styleMismatch},
+		{"r 2:N", "r 1:N", styleMismatch},
+		{"r 1:N", "r", styleMismatch},
+		{"r/1 1:N", "r/2 2:N", styleSlash},
+		{"r x", "r y", styleIdentical},
+	}
+	for _, test := range tests {
+		if get := mateStyle(test.h1, test.h2); get != test.want {
+			t.Errorf("%q, %q: want %d, get %d", test.h1, test.h2,
+				test.want, get)
+		}
+	}
+	in := ">x\nAC\n>y desc\nAC\n"
+	rep, _ = VerifyPairs(strings.NewReader(in), strings.NewReader(in))
+	if !rep.OK() || rep.FirstMismatch != -1 || rep.Identical != 2 {
+		t.Errorf("identical files: get %+v", rep)
+	}
+}